@@ -11,7 +11,8 @@ import (
 )
 
 func main() {
-	fmt.Println("=== Voltage Go Wrapper - Initialization Example ===\n")
+	fmt.Println("=== Voltage Go Wrapper - Initialization Example ===")
+	fmt.Println()
 
 	// Step 1: Load configuration
 	fmt.Println("Step 1: Loading configuration...")
@@ -30,21 +31,24 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
 	}
-	fmt.Println("✓ Client created successfully\n")
+	fmt.Println("✓ Client created successfully")
+	fmt.Println()
 
 	// Step 3: Initialize the client
 	fmt.Println("Step 3: Initializing Voltage library...")
 	if err := client.Initialize(); err != nil {
 		log.Fatalf("Failed to initialize: %v", err)
 	}
-	fmt.Println("✓ Voltage library initialized successfully\n")
+	fmt.Println("✓ Voltage library initialized successfully")
+	fmt.Println()
 
 	// Step 4: Verify client is healthy
 	fmt.Println("Step 4: Performing health check...")
 	if err := client.HealthCheck(); err != nil {
 		log.Printf("WARNING: Health check failed: %v\n", err)
 	} else {
-		fmt.Println("✓ Health check passed\n")
+		fmt.Println("✓ Health check passed")
+		fmt.Println()
 	}
 
 	// Step 5: Get client information
@@ -61,14 +65,16 @@ func main() {
 	fmt.Println("Step 6: Simulating application work...")
 	fmt.Println("  (In a real application, you would perform encryption/decryption here)")
 	time.Sleep(2 * time.Second)
-	fmt.Println("✓ Work completed\n")
+	fmt.Println("✓ Work completed")
+	fmt.Println()
 
 	// Step 7: Perform another health check
 	fmt.Println("Step 7: Final health check...")
 	if err := client.HealthCheck(); err != nil {
 		log.Printf("WARNING: Health check failed: %v\n", err)
 	} else {
-		fmt.Println("✓ Health check passed\n")
+		fmt.Println("✓ Health check passed")
+		fmt.Println()
 	}
 
 	// Step 8: Clean shutdown
@@ -76,7 +82,8 @@ func main() {
 	if err := client.Close(); err != nil {
 		log.Printf("WARNING: Error during shutdown: %v\n", err)
 	} else {
-		fmt.Println("✓ Client closed successfully\n")
+		fmt.Println("✓ Client closed successfully")
+		fmt.Println()
 	}
 
 	fmt.Println("=== Example completed successfully ===")
@@ -121,7 +128,8 @@ func loadConfiguration() (*config.Config, error) {
 
 // Example of error handling with Voltage errors
 func demonstrateErrorHandling(client *vlock.Client) {
-	fmt.Println("\n=== Error Handling Examples ===\n")
+	fmt.Println("\n=== Error Handling Examples ===")
+	fmt.Println()
 
 	// Example 1: Handle initialization errors
 	err := client.Initialize()
@@ -149,7 +157,8 @@ func demonstrateErrorHandling(client *vlock.Client) {
 
 // Example of using functional options with NewClient
 func demonstrateClientOptions() {
-	fmt.Println("\n=== Client Options Examples ===\n")
+	fmt.Println("\n=== Client Options Examples ===")
+	fmt.Println()
 
 	cfg := &config.Config{
 		AppName:         "VoltageExampleApp",
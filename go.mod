@@ -2,4 +2,4 @@ module github.com/daveaugustus/vlock
 
 go 1.24.4
 
-require github.com/kelseyhightower/envconfig v1.4.0 // indirect
+require github.com/kelseyhightower/envconfig v1.4.0
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -36,11 +38,24 @@ type Config struct {
 	NetworkTimeout     int    `envconfig:"FP_NETWORKTIMEOUT" default:"10"`
 	DisableCRLChecking bool   `envconfig:"FP_DISABLECRLCHECKING" default:"false"`
 	DefaultCryptID     string `envconfig:"FP_DEFAULT_CRYPTID" required:"false"`
-	LogLevel           int    `envconfig:"FP_LOGLEVEL" default:"2"`
-	LogFile            string `envconfig:"FP_LOGFILE" required:"false"`
+
+	// LogLevel is 0 = error, 1 = warn, 2 = info, 3 = debug, 4 = trace, negative to disable
+	// vlock only logs when LogFile is set or LogLevelSet reports true, so a client left at
+	// the default level stays silent
+	LogLevel int    `envconfig:"FP_LOGLEVEL" default:"2"`
+	LogFile  string `envconfig:"FP_LOGFILE" required:"false"`
+
+	// Retry settings for transient Voltage errors (timeouts, connection failures, service unavailable)
+	RetryMaxAttempts      int `envconfig:"FP_RETRY_MAXATTEMPTS" default:"3"`        // Total attempts, 1 disables retries
+	RetryInitialBackoffMs int `envconfig:"FP_RETRY_INITIALBACKOFFMS" default:"100"` // Delay before the first retry
+	RetryMaxBackoffMs     int `envconfig:"FP_RETRY_MAXBACKOFFMS" default:"2000"`    // Upper bound for a single delay
+	RetryMaxElapsedMs     int `envconfig:"FP_RETRY_MAXELAPSEDMS" default:"0"`       // Overall budget across all attempts, 0 for no limit
 
 	// Internal
-	ConfigFilePath string `envconfig:"-"` // Not from environment
+	ConfigFilePath string                 `envconfig:"-"` // Not from environment
+	Sources        map[string]ValueSource `envconfig:"-"` // Where each field's value came from, by field name
+
+	defaultLogLevel int // LogLevel this Config started with: 2 from NewConfig, 0 for a struct literal
 }
 
 // Environment variable names mapped to configuration fields
@@ -58,39 +73,158 @@ const (
 	EnvDEKSharedSecret      = "FP_DEFAULT_SHAREDSECRET"
 	EnvDEKUsername          = "FP_DEFAULT_USERNAME"
 	EnvDEKPassword          = "FP_DEFAULT_PASSWORD"
+	EnvRetryMaxAttempts     = "FP_RETRY_MAXATTEMPTS"
+	EnvRetryInitialBackoff  = "FP_RETRY_INITIALBACKOFFMS"
+	EnvRetryMaxBackoff      = "FP_RETRY_MAXBACKOFFMS"
+	EnvRetryMaxElapsed      = "FP_RETRY_MAXELAPSEDMS"
 )
 
 // ConfigError represents a configuration-related error
+// File and Line are set when the error can be traced to a position in a configuration file
 type ConfigError struct {
 	Field   string
 	Message string
+	File    string
+	Line    int
 }
 
 func (e *ConfigError) Error() string {
+	msg := fmt.Sprintf("configuration error: %s", e.Message)
 	if e.Field != "" {
-		return fmt.Sprintf("configuration error [%s]: %s", e.Field, e.Message)
+		msg = fmt.Sprintf("configuration error [%s]: %s", e.Field, e.Message)
+	}
+
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, msg)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, msg)
+	default:
+		return msg
 	}
-	return fmt.Sprintf("configuration error: %s", e.Message)
 }
 
 // NewConfig creates a new configuration with default values
 func NewConfig() *Config {
 	return &Config{
-		NetworkTimeout:     10,
-		DisableCRLChecking: false,
-		LogLevel:           2,
+		NetworkTimeout:        10,
+		DisableCRLChecking:    false,
+		LogLevel:              2,
+		defaultLogLevel:       2,
+		RetryMaxAttempts:      3,
+		RetryInitialBackoffMs: 100,
+		RetryMaxBackoffMs:     2000,
+	}
+}
+
+// LogLevelSet reports whether LogLevel was chosen rather than left at its default: read from
+// the .cfg file or FP_LOGLEVEL, or set in code to a value other than the one the Config started
+// with (2 from NewConfig and LoadConfig, 0 for a Config built as a struct literal)
+func (c *Config) LogLevelSet() bool {
+	if _, ok := c.Source("LogLevel"); ok {
+		return true
+	}
+	return c.LogLevel != c.defaultLogLevel
+}
+
+// LoadOption customizes how LoadConfig reads and validates configuration
+type LoadOption func(*loadOptions)
+
+// loadOptions holds the settings applied by LoadOption values
+type loadOptions struct {
+	deepValidation bool
+	secrets        *SecretResolver
+	providers      map[string]SecretProvider
+	environment    string
+	strict         bool
+	freshSecrets   bool // Resolve secrets under WithoutSecretCache, as Watcher reloads do
+}
+
+// WithDeepValidation makes LoadConfig run ValidateDeep instead of Validate,
+// cross-checking the .cfg values against the referenced vsconfig.xml
+func WithDeepValidation() LoadOption {
+	return func(o *loadOptions) {
+		o.deepValidation = true
+	}
+}
+
+// WithSecretResolver makes LoadConfig resolve secret references with resolver
+// instead of the built-in providers of NewSecretResolver
+func WithSecretResolver(resolver *SecretResolver) LoadOption {
+	return func(o *loadOptions) {
+		o.secrets = resolver
+	}
+}
+
+// WithSecretProvider makes LoadConfig resolve "scheme://" references with provider,
+// in addition to the providers of the resolver in use; the resolver itself is not modified
+// exec:// references are only resolved when enabled this way:
+//
+//	config.LoadConfig(path, config.WithSecretProvider("exec", config.ExecProvider{}))
+func WithSecretProvider(scheme string, provider SecretProvider) LoadOption {
+	return func(o *loadOptions) {
+		if o.providers == nil {
+			o.providers = make(map[string]SecretProvider)
+		}
+		o.providers[strings.ToLower(scheme)] = provider
+	}
+}
+
+// resolver returns the resolver LoadConfig uses: o.secrets with the WithSecretProvider providers added
+func (o *loadOptions) resolver() *SecretResolver {
+	if len(o.providers) == 0 {
+		return o.secrets
+	}
+	resolver := o.secrets.clone()
+	for scheme, provider := range o.providers {
+		resolver.Register(scheme, provider)
+	}
+	return resolver
+}
+
+// WithStrictParsing makes LoadConfig reject .cfg files with malformed lines, unknown keys,
+// keys set twice for the same environment, or values that are not valid numbers or booleans
+// All problems are reported together as ValidationErrors carrying file:line positions
+func WithStrictParsing() LoadOption {
+	return func(o *loadOptions) {
+		o.strict = true
+	}
+}
+
+// WithEnvironment makes LoadConfig overlay the [env] section of the .cfg file and sets AppEnv to env,
+// regardless of fp_appEnv and FP_APPENV
+func WithEnvironment(env string) LoadOption {
+	return func(o *loadOptions) {
+		o.environment = strings.ToUpper(env)
 	}
 }
 
 // LoadConfig loads configuration from a file and applies environment variable overrides
-func LoadConfig(configPath string) (*Config, error) {
+// Values in the [common] section apply everywhere; the section named after the environment
+// (WithEnvironment, FP_APPENV or fp_appEnv, in that order) overlays them
+// Credential values may be secret references such as file:///run/secrets/dek; see ResolveSecrets
+func LoadConfig(configPath string, opts ...LoadOption) (*Config, error) {
+	return loadConfig(configPath, newLoadOptions(opts))
+}
+
+// newLoadOptions applies opts to the defaults
+func newLoadOptions(opts []LoadOption) loadOptions {
+	options := loadOptions{secrets: NewSecretResolver()}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// loadConfig is LoadConfig with the options already applied
+func loadConfig(configPath string, options loadOptions) (*Config, error) {
 	config := NewConfig()
 	config.ConfigFilePath = configPath
 
 	// Load from file if path is provided and file exists
 	if configPath != "" {
 		if _, err := os.Stat(configPath); err == nil {
-			if err := config.loadFromFile(configPath); err != nil {
+			if err := config.loadFromFile(configPath, options); err != nil {
 				return nil, fmt.Errorf("failed to load config file: %w", err)
 			}
 		} else if !os.IsNotExist(err) {
@@ -103,9 +237,26 @@ func LoadConfig(configPath string) (*Config, error) {
 	if err := config.loadFromEnv(); err != nil {
 		return nil, fmt.Errorf("failed to load environment variables: %w", err)
 	}
+	if options.environment != "" {
+		config.AppEnv = options.environment
+		delete(config.Sources, "AppEnv")
+	}
+
+	// Replace secret references with the secrets they name
+	ctx := context.Background()
+	if options.freshSecrets {
+		ctx = WithoutSecretCache(ctx)
+	}
+	if err := config.ResolveSecrets(ctx, options.resolver()); err != nil {
+		return nil, err
+	}
 
 	// Validate required fields
-	if err := config.Validate(); err != nil {
+	validate := config.Validate
+	if options.deepValidation {
+		validate = config.ValidateDeep
+	}
+	if err := validate(); err != nil {
 		return nil, err
 	}
 
@@ -113,42 +264,47 @@ func LoadConfig(configPath string) (*Config, error) {
 }
 
 // loadFromFile reads configuration from a .cfg file (INI format)
-func (c *Config) loadFromFile(filePath string) error {
+// Common entries are applied first, then those of the section for the environment (see selectEnvironment)
+func (c *Config) loadFromFile(filePath string, options loadOptions) error {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
-			continue
-		}
+	f := parseCfg(data)
+	problems := checkSections(filePath, f)
+	if options.strict {
+		problems = append(problems, checkStrict(filePath, f)...)
+	}
+	if len(problems) > 0 {
+		return problems
+	}
 
-		// Skip section headers like [ProtectorConfig]
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			continue
+	env := selectEnvironment(f.entries, options.environment)
+	for _, e := range f.entries {
+		if commonSection(e.Section) {
+			c.applyEntry(filePath, e)
 		}
-
-		// Parse key=value
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			continue
+	}
+	for _, e := range f.entries {
+		if env != "" && environmentSection(e.Section) == env {
+			c.applyEntry(filePath, e)
 		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Map configuration keys to struct fields
-		c.setConfigValue(key, value)
 	}
 
 	return nil
 }
 
+// applyEntry sets the field named by a .cfg entry and records where it came from
+func (c *Config) applyEntry(filePath string, e cfgEntry) {
+	field, ok := fileKeys[e.Key]
+	if !ok {
+		return
+	}
+	c.setConfigValue(e.Key, e.Value)
+	c.setSource(field, ValueSource{File: filePath, Line: e.Line, Section: e.Section})
+}
+
 // setConfigValue maps a configuration key to the appropriate struct field
 func (c *Config) setConfigValue(key, value string) {
 	switch key {
@@ -190,6 +346,22 @@ func (c *Config) setConfigValue(key, value string) {
 		}
 	case "fp_disableCRLChecking":
 		c.DisableCRLChecking = strings.ToLower(value) == "true"
+	case "fp_retryMaxAttempts":
+		if val, err := strconv.Atoi(value); err == nil {
+			c.RetryMaxAttempts = val
+		}
+	case "fp_retryInitialBackoffMs":
+		if val, err := strconv.Atoi(value); err == nil {
+			c.RetryInitialBackoffMs = val
+		}
+	case "fp_retryMaxBackoffMs":
+		if val, err := strconv.Atoi(value); err == nil {
+			c.RetryMaxBackoffMs = val
+		}
+	case "fp_retryMaxElapsedMs":
+		if val, err := strconv.Atoi(value); err == nil {
+			c.RetryMaxElapsedMs = val
+		}
 	}
 }
 
@@ -216,6 +388,10 @@ func (c *Config) loadFromEnv() error {
 		DefaultCryptID       string `envconfig:"FP_DEFAULT_CRYPTID"`
 		LogLevel             int    `envconfig:"FP_LOGLEVEL"`
 		LogFile              string `envconfig:"FP_LOGFILE"`
+		RetryMaxAttempts     int    `envconfig:"FP_RETRY_MAXATTEMPTS"`
+		RetryInitialBackoff  int    `envconfig:"FP_RETRY_INITIALBACKOFFMS"`
+		RetryMaxBackoff      int    `envconfig:"FP_RETRY_MAXBACKOFFMS"`
+		RetryMaxElapsed      int    `envconfig:"FP_RETRY_MAXELAPSEDMS"`
 	}
 
 	var overrides envOverrides
@@ -275,37 +451,66 @@ func (c *Config) loadFromEnv() error {
 	if overrides.LogFile != "" {
 		c.LogFile = overrides.LogFile
 	}
+	if os.Getenv("FP_RETRY_MAXATTEMPTS") != "" {
+		c.RetryMaxAttempts = overrides.RetryMaxAttempts
+	}
+	if os.Getenv("FP_RETRY_INITIALBACKOFFMS") != "" {
+		c.RetryInitialBackoffMs = overrides.RetryInitialBackoff
+	}
+	if os.Getenv("FP_RETRY_MAXBACKOFFMS") != "" {
+		c.RetryMaxBackoffMs = overrides.RetryMaxBackoff
+	}
+	if os.Getenv("FP_RETRY_MAXELAPSEDMS") != "" {
+		c.RetryMaxElapsedMs = overrides.RetryMaxElapsed
+	}
+
+	for name, field := range envFields {
+		if os.Getenv(name) != "" {
+			c.setSource(field, ValueSource{EnvVar: name})
+		}
+	}
 
 	return nil
 }
 
 // Validate ensures all required configuration parameters are present
 func (c *Config) Validate() error {
-	var errors []string
+	problems := c.fieldErrors()
+	if len(problems) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(problems))
+	for i, p := range problems {
+		messages[i] = p.Message
+	}
+	return &ConfigError{
+		Message: strings.Join(messages, "; "),
+	}
+}
+
+// fieldErrors returns one *ConfigError per failed required-field check
+func (c *Config) fieldErrors() []*ConfigError {
+	var errors []*ConfigError
 
 	// Required fields
 	if c.AppName == "" {
-		errors = append(errors, "AppName is required (set fp_appName or FP_APPNAME)")
+		errors = append(errors, &ConfigError{Field: "AppName", Message: "AppName is required (set fp_appName or FP_APPNAME)"})
 	}
 	if c.AppVersion == "" {
-		errors = append(errors, "AppVersion is required (set fp_appVersion or FP_APPVERSION)")
+		errors = append(errors, &ConfigError{Field: "AppVersion", Message: "AppVersion is required (set fp_appVersion or FP_APPVERSION)"})
 	}
 	if c.AppEnv == "" {
-		errors = append(errors, "AppEnv is required (set fp_appEnv or FP_APPENV)")
+		errors = append(errors, &ConfigError{Field: "AppEnv", Message: "AppEnv is required (set fp_appEnv or FP_APPENV)"})
 	}
 
 	// Validate AppEnv value
-	validEnvs := []string{"DEV", "QA", "CAT", "PROD"}
 	if c.AppEnv != "" {
-		valid := false
-		for _, env := range validEnvs {
-			if c.AppEnv == env {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			errors = append(errors, fmt.Sprintf("AppEnv must be one of: %s (got: %s)", strings.Join(validEnvs, ", "), c.AppEnv))
+		if !slices.Contains(validEnvironments, c.AppEnv) {
+			errors = append(errors, &ConfigError{
+				Field:   "AppEnv",
+				Message: fmt.Sprintf("AppEnv must be one of: %s (got: %s)", strings.Join(validEnvironments, ", "), c.AppEnv),
+			})
 		}
 	}
 
@@ -314,16 +519,32 @@ func (c *Config) Validate() error {
 	hasDEK := c.DEKSharedSecret != "" || (c.DEKUsername != "" && c.DEKPassword != "")
 
 	if !hasKEK && !hasDEK {
-		errors = append(errors, "At least one authentication method must be configured (KEK or DEK credentials)")
-	}
-
-	if len(errors) > 0 {
-		return &ConfigError{
-			Message: strings.Join(errors, "; "),
+		errors = append(errors, &ConfigError{
+			Field:   "Credentials",
+			Message: "At least one authentication method must be configured (KEK or DEK credentials)",
+		})
+	}
+
+	// Retry settings must not be negative
+	retrySettings := []struct {
+		field string
+		value int
+	}{
+		{"RetryMaxAttempts", c.RetryMaxAttempts},
+		{"RetryInitialBackoffMs", c.RetryInitialBackoffMs},
+		{"RetryMaxBackoffMs", c.RetryMaxBackoffMs},
+		{"RetryMaxElapsedMs", c.RetryMaxElapsedMs},
+	}
+	for _, setting := range retrySettings {
+		if setting.value < 0 {
+			errors = append(errors, &ConfigError{
+				Field:   setting.field,
+				Message: fmt.Sprintf("%s cannot be negative (got: %d)", setting.field, setting.value),
+			})
 		}
 	}
 
-	return nil
+	return errors
 }
 
 // String returns a string representation of the configuration (with sensitive data masked)
@@ -20,6 +20,26 @@ func TestNewConfig(t *testing.T) {
 	if config.LogLevel != 2 {
 		t.Errorf("Expected LogLevel to be 2, got %d", config.LogLevel)
 	}
+	if config.LogLevelSet() {
+		t.Error("NewConfig's LogLevel should not count as set")
+	}
+}
+
+func TestLogLevelSet(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		want   bool
+	}{
+		{"struct literal without LogLevel", &Config{}, false},
+		{"struct literal with LogLevel", &Config{LogLevel: 2}, true},
+		{"NewConfig changed in code", func() *Config { c := NewConfig(); c.LogLevel = 3; return c }(), true},
+	}
+	for _, tt := range tests {
+		if got := tt.config.LogLevelSet(); got != tt.want {
+			t.Errorf("%s: LogLevelSet() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
 }
 
 func TestLoadConfigFromFile(t *testing.T) {
@@ -381,6 +401,11 @@ func TestConfigError(t *testing.T) {
 			err:      &ConfigError{Message: "general error"},
 			expected: "configuration error: general error",
 		},
+		{
+			name:     "Error with position",
+			err:      &ConfigError{Field: "cryptId", Message: "name attribute is required", File: "vsconfig.xml", Line: 12},
+			expected: "vsconfig.xml:12: configuration error [cryptId]: name attribute is required",
+		},
 	}
 
 	for _, tt := range tests {
@@ -486,3 +511,46 @@ func containsSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRetrySettings(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "test.cfg")
+
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=secret
+fp_retryMaxAttempts=5
+fp_retryInitialBackoffMs=250
+fp_retryMaxBackoffMs=4000
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	os.Setenv("FP_RETRY_MAXELAPSEDMS", "10000")
+	defer os.Unsetenv("FP_RETRY_MAXELAPSEDMS")
+
+	config, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	if config.RetryMaxAttempts != 5 {
+		t.Errorf("Expected RetryMaxAttempts 5, got %d", config.RetryMaxAttempts)
+	}
+	if config.RetryInitialBackoffMs != 250 {
+		t.Errorf("Expected RetryInitialBackoffMs 250, got %d", config.RetryInitialBackoffMs)
+	}
+	if config.RetryMaxBackoffMs != 4000 {
+		t.Errorf("Expected RetryMaxBackoffMs 4000, got %d", config.RetryMaxBackoffMs)
+	}
+	if config.RetryMaxElapsedMs != 10000 {
+		t.Errorf("Expected RetryMaxElapsedMs from env 10000, got %d", config.RetryMaxElapsedMs)
+	}
+
+	config.RetryMaxAttempts = -1
+	if err := config.Validate(); err == nil || !contains(err.Error(), "RetryMaxAttempts") {
+		t.Errorf("Expected negative RetryMaxAttempts to fail validation, got: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultSecretTimeout bounds how long a secret provider may take, including exec:// helpers
+const DefaultSecretTimeout = 10 * time.Second
+
+// SecretProvider resolves secret references of one scheme
+// A credential value "scheme://ref" is resolved by the provider registered for scheme,
+// which receives ref, the part after "://"
+type SecretProvider interface {
+	Secret(ctx context.Context, ref string) (string, error)
+}
+
+// SecretProviderFunc adapts a function to SecretProvider
+type SecretProviderFunc func(ctx context.Context, ref string) (string, error)
+
+// Secret calls f
+func (f SecretProviderFunc) Secret(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// SecretResolver maps schemes to SecretProviders
+type SecretResolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver returns a resolver with the built-in providers:
+//   - file:///run/secrets/dek reads a file, such as a mounted Kubernetes secret
+//   - env://NAME reads environment variable NAME
+//   - vault://secret/data/voltage#dek reads a Vault KV field, using VaultConfigFromEnv
+//
+// One trailing newline is removed from file secrets. exec:// references, which run
+// a command, are rejected unless ExecProvider is registered for them
+func NewSecretResolver() *SecretResolver {
+	r := &SecretResolver{providers: make(map[string]SecretProvider)}
+	r.Register("file", SecretProviderFunc(fileSecret))
+	r.Register("env", SecretProviderFunc(envSecret))
+	r.Register("vault", defaultVault)
+	return r
+}
+
+// clone returns a resolver with the same providers that can be changed independently of r
+func (r *SecretResolver) clone() *SecretResolver {
+	c := &SecretResolver{providers: make(map[string]SecretProvider, len(r.providers))}
+	for scheme, provider := range r.providers {
+		c.providers[scheme] = provider
+	}
+	return c
+}
+
+// Register makes r resolve "scheme://" references with provider, replacing any earlier one
+func (r *SecretResolver) Register(scheme string, provider SecretProvider) {
+	r.providers[strings.ToLower(scheme)] = provider
+}
+
+// Resolve returns the secret value refers to, or value itself if it is not a reference
+// Errors name the reference but never include a secret
+func (r *SecretResolver) Resolve(ctx context.Context, value string) (string, error) {
+	scheme, ref, ok := splitSecretRef(value)
+	if !ok {
+		return value, nil
+	}
+	provider, registered := r.providers[scheme]
+	if !registered && scheme == "exec" {
+		// Never hand a command line to the backend as a credential
+		return "", fmt.Errorf("cannot resolve secret %s: exec:// secrets are disabled; enable them with WithSecretProvider(\"exec\", ExecProvider{})", value)
+	}
+	if !registered {
+		return value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultSecretTimeout)
+	defer cancel()
+
+	secret, err := provider.Secret(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve secret %s: %w", value, err)
+	}
+	if secret == "" {
+		return "", fmt.Errorf("secret %s is empty", value)
+	}
+	return secret, nil
+}
+
+// skipCacheKey is the context key set by WithoutSecretCache
+type skipCacheKey struct{}
+
+// WithoutSecretCache returns a context that makes caching providers, such as VaultProvider,
+// read secrets afresh instead of reusing cached values; Watcher reloads resolve with it
+func WithoutSecretCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipCacheKey{}, true)
+}
+
+// skipSecretCache reports whether ctx comes from WithoutSecretCache
+func skipSecretCache(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipCacheKey{}).(bool)
+	return skip
+}
+
+// splitSecretRef splits "scheme://ref"; ok is false if value has no scheme
+func splitSecretRef(value string) (scheme, ref string, ok bool) {
+	scheme, ref, ok = strings.Cut(value, "://")
+	if !ok || scheme == "" || strings.ContainsAny(scheme, " \t/=") {
+		return "", "", false
+	}
+	return strings.ToLower(scheme), ref, true
+}
+
+// secretFields lists the credential fields that may hold secret references
+func (c *Config) secretFields() []struct {
+	name  string
+	value *string
+} {
+	return []struct {
+		name  string
+		value *string
+	}{
+		{"KEKCertPassphrase", &c.KEKCertPassphrase},
+		{"KEKSharedSecret", &c.KEKSharedSecret},
+		{"DEKSharedSecret", &c.DEKSharedSecret},
+		{"DEKUsername", &c.DEKUsername},
+		{"DEKPassword", &c.DEKPassword},
+	}
+}
+
+// ResolveSecrets replaces secret references in the credential fields
+// (KEKCertPassphrase, KEKSharedSecret, DEKSharedSecret, DEKUsername, DEKPassword)
+// with the secrets they name; LoadConfig calls it before validation
+func (c *Config) ResolveSecrets(ctx context.Context, resolver *SecretResolver) error {
+	var problems ValidationErrors
+	for _, field := range c.secretFields() {
+		secret, err := resolver.Resolve(ctx, *field.value)
+		if err != nil {
+			problems = append(problems, &ConfigError{Field: field.name, Message: err.Error(), File: c.ConfigFilePath})
+			continue
+		}
+		*field.value = secret
+	}
+	return problems.orNil()
+}
+
+func fileSecret(_ context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(data)), nil
+}
+
+func envSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// ExecProvider resolves exec:// references by running a helper command and using its output,
+// such as exec:///usr/local/bin/fetch-secret voltage/dek. The command is split on spaces
+// and run without a shell; one trailing newline is removed from its output
+// It is not registered by default, since anyone able to set a credential value
+// could then run commands wherever the configuration is loaded
+type ExecProvider struct{}
+
+// Secret runs command and returns its output
+func (ExecProvider) Secret(ctx context.Context, command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", fmt.Errorf("no command given")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("helper %s: %w", args[0], ctx.Err())
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("helper %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("helper %s: %w", args[0], err)
+	}
+	return trimNewline(string(out)), nil
+}
+
+// trimNewline removes one trailing "\n" or "\r\n"
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
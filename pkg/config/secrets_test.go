@@ -0,0 +1,195 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretResolverBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "dek")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	t.Setenv("VLOCK_TEST_SECRET", "env-secret")
+
+	resolver := NewSecretResolver()
+	resolver.Register("exec", ExecProvider{})
+	ctx := context.Background()
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"file://" + secretFile, "file-secret"},
+		{"env://VLOCK_TEST_SECRET", "env-secret"},
+		{"exec://echo exec-secret", "exec-secret"},
+		{"plain_secret", "plain_secret"},
+		{"https://not-a-secret-scheme", "https://not-a-secret-scheme"},
+		{"pass=word://x", "pass=word://x"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got, err := resolver.Resolve(ctx, tt.value)
+		if err != nil {
+			t.Errorf("Resolve(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSecretResolverErrors(t *testing.T) {
+	resolver := NewSecretResolver()
+	resolver.Register("exec", ExecProvider{})
+	ctx := context.Background()
+
+	for _, value := range []string{
+		"file:///nonexistent/vlock/secret",
+		"env://VLOCK_TEST_UNSET_SECRET",
+		"exec://false",
+		"exec://",
+	} {
+		if _, err := resolver.Resolve(ctx, value); err == nil || !strings.Contains(err.Error(), value) {
+			t.Errorf("Resolve(%q) error = %v, want an error naming the reference", value, err)
+		}
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	if _, err := resolver.Resolve(ctx, "file://"+empty); err == nil {
+		t.Error("Expected an empty secret to be rejected")
+	}
+}
+
+func TestSecretResolverExecDisabled(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	value := "exec://touch " + marker
+	if _, err := NewSecretResolver().Resolve(context.Background(), value); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("Expected exec:// to be rejected by default, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("The exec:// command ran although exec:// is disabled")
+	}
+
+	configPath := filepath.Join(t.TempDir(), "test.cfg")
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=exec://echo helper-secret
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(configPath); err == nil {
+		t.Error("Expected LoadConfig to reject exec:// by default")
+	}
+
+	resolver := NewSecretResolver()
+	cfg, err := LoadConfig(configPath, WithSecretResolver(resolver), WithSecretProvider("exec", ExecProvider{}))
+	if err != nil {
+		t.Fatalf("LoadConfig with exec:// enabled failed: %v", err)
+	}
+	if cfg.DEKSharedSecret != "helper-secret" {
+		t.Errorf("Expected the helper's output, got %q", cfg.DEKSharedSecret)
+	}
+	if _, registered := resolver.providers["exec"]; registered {
+		t.Error("WithSecretProvider should not modify the caller's resolver")
+	}
+}
+
+func TestSecretResolverRegister(t *testing.T) {
+	resolver := NewSecretResolver()
+	resolver.Register("Test", SecretProviderFunc(func(_ context.Context, ref string) (string, error) {
+		if ref == "missing" {
+			return "", errors.New("not found")
+		}
+		return "resolved-" + ref, nil
+	}))
+
+	got, err := resolver.Resolve(context.Background(), "test://dek")
+	if err != nil || got != "resolved-dek" {
+		t.Errorf("Resolve = %q, %v", got, err)
+	}
+	if _, err := resolver.Resolve(context.Background(), "TEST://missing"); err == nil {
+		t.Error("Expected the provider error to be returned")
+	}
+}
+
+func TestLoadConfigResolvesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "dek")
+	if err := os.WriteFile(secretFile, []byte("mounted-dek-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	t.Setenv("VLOCK_TEST_KEK_PASSPHRASE", "kek-passphrase")
+
+	configPath := filepath.Join(dir, "test.cfg")
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_kek_certPassphrase=env://VLOCK_TEST_KEK_PASSPHRASE
+fp_default_sharedSecret=file://` + secretFile + `
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DEKSharedSecret != "mounted-dek-secret" || cfg.KEKCertPassphrase != "kek-passphrase" {
+		t.Errorf("Secrets not resolved: DEK %q, KEK %q", cfg.DEKSharedSecret, cfg.KEKCertPassphrase)
+	}
+
+	// A secret given by environment variable can be a reference too
+	t.Setenv(EnvDEKSharedSecret, "env://VLOCK_TEST_KEK_PASSPHRASE")
+	cfg, err = LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DEKSharedSecret != "kek-passphrase" {
+		t.Errorf("Expected the environment reference to be resolved, got %q", cfg.DEKSharedSecret)
+	}
+}
+
+func TestLoadConfigSecretError(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "test.cfg")
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=file:///nonexistent/vlock/dek
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	_, err := LoadConfig(configPath)
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Field != "DEKSharedSecret" || cfgErr.File != configPath {
+		t.Fatalf("Expected a DEKSharedSecret ConfigError, got %v", err)
+	}
+
+	// A custom resolver replaces the built-in providers
+	resolver := &SecretResolver{providers: map[string]SecretProvider{}}
+	resolver.Register("file", SecretProviderFunc(func(context.Context, string) (string, error) {
+		return "stub-secret", nil
+	}))
+	cfg, err := LoadConfig(configPath, WithSecretResolver(resolver))
+	if err != nil {
+		t.Fatalf("LoadConfig with a custom resolver failed: %v", err)
+	}
+	if cfg.DEKSharedSecret != "stub-secret" {
+		t.Errorf("Expected the custom provider's secret, got %q", cfg.DEKSharedSecret)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// validEnvironments lists the accepted AppEnv values, which double as .cfg section names
+var validEnvironments = []string{"DEV", "QA", "CAT", "PROD"}
+
+// commonSections lists the sections that apply to all environments, as do keys before the first header
+var commonSections = []string{"common", "ProtectorConfig"}
+
+// fileKeys maps .cfg keys to the Config fields they set
+var fileKeys = map[string]string{
+	"fp_appName":               "AppName",
+	"fp_appVersion":            "AppVersion",
+	"fp_appEnv":                "AppEnv",
+	"fp_simpleAPI_installPath": "SimpleAPIInstallPath",
+	"fp_trustStore_path":       "TrustStorePath",
+	"XMLConfig":                "XMLConfigPath",
+	"fp_kek_certPath":          "KEKCertPath",
+	"fp_kek_certPassphrase":    "KEKCertPassphrase",
+	"fp_kek_sharedSecret":      "KEKSharedSecret",
+	"fp_default_sharedSecret":  "DEKSharedSecret",
+	"fp_default_userName":      "DEKUsername",
+	"fp_default_password":      "DEKPassword",
+	"DefaultCryptId":           "DefaultCryptID",
+	"LogLevel":                 "LogLevel",
+	"LogFile":                  "LogFile",
+	"fp_networkTimeout":        "NetworkTimeout",
+	"fp_disableCRLChecking":    "DisableCRLChecking",
+	"fp_retryMaxAttempts":      "RetryMaxAttempts",
+	"fp_retryInitialBackoffMs": "RetryInitialBackoffMs",
+	"fp_retryMaxBackoffMs":     "RetryMaxBackoffMs",
+	"fp_retryMaxElapsedMs":     "RetryMaxElapsedMs",
+}
+
+// envFields maps environment variables to the Config fields they override
+var envFields = map[string]string{
+	EnvAppName:              "AppName",
+	EnvAppVersion:           "AppVersion",
+	EnvAppEnv:               "AppEnv",
+	EnvSimpleAPIInstallPath: "SimpleAPIInstallPath",
+	EnvTrustStorePath:       "TrustStorePath",
+	"FP_XMLCONFIG":          "XMLConfigPath",
+	EnvKEKCertPath:          "KEKCertPath",
+	EnvKEKCertPassphrase:    "KEKCertPassphrase",
+	EnvKEKSharedSecret:      "KEKSharedSecret",
+	EnvDEKSharedSecret:      "DEKSharedSecret",
+	EnvDEKUsername:          "DEKUsername",
+	EnvDEKPassword:          "DEKPassword",
+	EnvNetworkTimeout:       "NetworkTimeout",
+	EnvDisableCRLChecking:   "DisableCRLChecking",
+	"FP_DEFAULT_CRYPTID":    "DefaultCryptID",
+	"FP_LOGLEVEL":           "LogLevel",
+	"FP_LOGFILE":            "LogFile",
+	EnvRetryMaxAttempts:     "RetryMaxAttempts",
+	EnvRetryInitialBackoff:  "RetryInitialBackoffMs",
+	EnvRetryMaxBackoff:      "RetryMaxBackoffMs",
+	EnvRetryMaxElapsed:      "RetryMaxElapsedMs",
+}
+
+// ValueSource records where the effective value of a Config field came from
+type ValueSource struct {
+	File    string // .cfg file the value was read from
+	Line    int    // Line in File
+	Section string // Section header the value appeared under, empty before the first header
+	EnvVar  string // Environment variable that overrode any file value
+}
+
+// String describes the source, e.g. "voltageprotector.cfg:12 [PROD]" or "environment variable FP_APPENV"
+func (s ValueSource) String() string {
+	if s.EnvVar != "" {
+		return "environment variable " + s.EnvVar
+	}
+	location := fmt.Sprintf("%s:%d", s.File, s.Line)
+	if s.Section != "" {
+		location += " [" + s.Section + "]"
+	}
+	return location
+}
+
+// Source reports where the value of field, a Config field name such as "NetworkTimeout", came from
+// Fields without a source hold their defaults or an environment chosen with WithEnvironment
+func (c *Config) Source(field string) (ValueSource, bool) {
+	source, ok := c.Sources[field]
+	return source, ok
+}
+
+// setSource records the source of field
+func (c *Config) setSource(field string, source ValueSource) {
+	if c.Sources == nil {
+		c.Sources = make(map[string]ValueSource)
+	}
+	c.Sources[field] = source
+}
+
+// cfgEntry is one key=value line of a .cfg file
+type cfgEntry struct {
+	Key     string
+	Value   string
+	Section string
+	Line    int
+}
+
+// cfgFile is a parsed .cfg file
+type cfgFile struct {
+	entries   []cfgEntry
+	sections  []cfgLine // Section headers, with Text holding the name
+	malformed []cfgLine // Lines that are neither entries nor section headers
+}
+
+// parseCfg splits a .cfg file into its key=value entries, tagged with their section
+func parseCfg(data []byte) cfgFile {
+	var f cfgFile
+	section := ""
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			f.sections = append(f.sections, cfgLine{Line: i + 1, Text: section})
+			continue
+		}
+
+		// Parse key=value
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			f.malformed = append(f.malformed, cfgLine{Line: i + 1, Text: line})
+			continue
+		}
+		f.entries = append(f.entries, cfgEntry{
+			Key:     key,
+			Value:   strings.TrimSpace(value),
+			Section: section,
+			Line:    i + 1,
+		})
+	}
+	return f
+}
+
+// environmentSection returns the environment a section overlays, or "" for other sections
+func environmentSection(section string) string {
+	for _, env := range validEnvironments {
+		if strings.EqualFold(section, env) {
+			return env
+		}
+	}
+	return ""
+}
+
+// commonSection reports whether a section applies to all environments
+func commonSection(section string) bool {
+	if section == "" {
+		return true
+	}
+	for _, name := range commonSections {
+		if strings.EqualFold(section, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSections reports section headers that are neither common nor an environment,
+// so that a misspelled [PRD] does not go unnoticed, and fp_appEnv set inside an
+// environment section, which would contradict the environment that selected it
+func checkSections(filePath string, f cfgFile) ValidationErrors {
+	var problems ValidationErrors
+	known := append(slices.Clone(commonSections), validEnvironments...)
+	for _, s := range f.sections {
+		if commonSection(s.Text) || environmentSection(s.Text) != "" {
+			continue
+		}
+		message := fmt.Sprintf("unknown section [%s]", s.Text)
+		if suggestion := suggest(s.Text, known); suggestion != "" {
+			message += fmt.Sprintf(" (did you mean [%s]?)", suggestion)
+		}
+		problems = append(problems, &ConfigError{
+			Message: message + "; sections must be [common], [ProtectorConfig] or one of " + strings.Join(validEnvironments, ", "),
+			File:    filePath,
+			Line:    s.Line,
+		})
+	}
+	for _, e := range f.entries {
+		if e.Key == "fp_appEnv" && environmentSection(e.Section) != "" {
+			problems = append(problems, &ConfigError{
+				Field:   "AppEnv",
+				Message: fmt.Sprintf("fp_appEnv cannot be set in environment section [%s]; set it in [common] or use FP_APPENV", e.Section),
+				File:    filePath,
+				Line:    e.Line,
+			})
+		}
+	}
+	return problems
+}
+
+// selectEnvironment picks the section to overlay: the explicit environment if given,
+// then FP_APPENV, then the last fp_appEnv among the common entries
+func selectEnvironment(entries []cfgEntry, explicit string) string {
+	env := explicit
+	if env == "" {
+		env = os.Getenv(EnvAppEnv)
+	}
+	if env == "" {
+		for _, e := range entries {
+			if e.Key == "fp_appEnv" && commonSection(e.Section) {
+				env = e.Value
+			}
+		}
+	}
+	return strings.ToUpper(env)
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sectionedConfig = `# Shared by every environment
+[common]
+fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=common-secret
+fp_networkTimeout=10
+
+[dev]
+LogLevel=3
+
+[PROD]
+fp_networkTimeout=30
+fp_default_sharedSecret=prod-secret
+fp_disableCRLChecking=false
+
+[ProtectorConfig]
+DefaultCryptId=SSN_Internal
+`
+
+func writeSectionedConfig(t *testing.T) string {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "voltageprotector.cfg")
+	if err := os.WriteFile(configPath, []byte(sectionedConfig), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadConfigSectionFromAppEnv(t *testing.T) {
+	configPath := writeSectionedConfig(t)
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.AppEnv != "DEV" || cfg.LogLevel != 3 || cfg.NetworkTimeout != 10 || cfg.DEKSharedSecret != "common-secret" {
+		t.Errorf("DEV overlay not applied: %+v", cfg)
+	}
+	if cfg.DefaultCryptID != "SSN_Internal" {
+		t.Errorf("[ProtectorConfig] should apply to every environment, got DefaultCryptID %q", cfg.DefaultCryptID)
+	}
+
+	source, ok := cfg.Source("LogLevel")
+	if !ok || source.Section != "dev" || source.Line != 10 || source.File != configPath {
+		t.Errorf("Unexpected LogLevel source: %+v", source)
+	}
+	if got, want := source.String(), configPath+":10 [dev]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if source, _ := cfg.Source("NetworkTimeout"); source.Section != "common" {
+		t.Errorf("Unexpected NetworkTimeout source: %+v", source)
+	}
+	if _, ok := cfg.Source("RetryMaxAttempts"); ok {
+		t.Error("Defaults should have no source")
+	}
+}
+
+func TestLoadConfigSectionFromEnvVar(t *testing.T) {
+	configPath := writeSectionedConfig(t)
+	t.Setenv(EnvAppEnv, "prod")
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.AppEnv != "PROD" || cfg.NetworkTimeout != 30 || cfg.DEKSharedSecret != "prod-secret" || cfg.LogLevel != 2 {
+		t.Errorf("PROD overlay not applied: %+v", cfg)
+	}
+	if source, _ := cfg.Source("AppEnv"); source.EnvVar != EnvAppEnv || source.String() != "environment variable FP_APPENV" {
+		t.Errorf("Unexpected AppEnv source: %+v", source)
+	}
+	if source, _ := cfg.Source("DEKSharedSecret"); source.Section != "PROD" || source.Line != 14 {
+		t.Errorf("Unexpected DEKSharedSecret source: %+v", source)
+	}
+}
+
+func TestLoadConfigWithEnvironment(t *testing.T) {
+	configPath := writeSectionedConfig(t)
+	t.Setenv(EnvAppEnv, "DEV")
+
+	cfg, err := LoadConfig(configPath, WithEnvironment("prod"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.AppEnv != "PROD" || cfg.NetworkTimeout != 30 {
+		t.Errorf("WithEnvironment should win over FP_APPENV: %+v", cfg)
+	}
+	if _, ok := cfg.Source("AppEnv"); ok {
+		t.Error("AppEnv chosen by WithEnvironment should have no source")
+	}
+
+	// An environment without a section gets only the common values
+	cfg, err = LoadConfig(configPath, WithEnvironment("QA"))
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.AppEnv != "QA" || cfg.NetworkTimeout != 10 || cfg.LogLevel != 2 {
+		t.Errorf("Unexpected QA configuration: %+v", cfg)
+	}
+}
+
+func TestFileKeysMatchFields(t *testing.T) {
+	fields := reflect.TypeOf(Config{})
+	for key, field := range fileKeys {
+		if _, ok := fields.FieldByName(field); !ok {
+			t.Errorf("%s maps to unknown field %s", key, field)
+			continue
+		}
+		cfg := &Config{}
+		cfg.setConfigValue(key, "7")
+		if key == "fp_disableCRLChecking" {
+			cfg.setConfigValue(key, "true")
+		}
+		if reflect.ValueOf(cfg).Elem().FieldByName(field).IsZero() {
+			t.Errorf("%s does not set %s", key, field)
+		}
+	}
+	for name, field := range envFields {
+		if _, ok := fields.FieldByName(field); !ok {
+			t.Errorf("%s maps to unknown field %s", name, field)
+		}
+	}
+}
+
+func TestLoadConfigRejectsUnknownSections(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "voltageprotector.cfg")
+	configContent := `[common]
+fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=secret
+
+[PRD]
+fp_disableCRLChecking=true
+
+[Production]
+fp_networkTimeout=30
+
+[PROD]
+fp_appEnv=DEV
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// Without strict parsing too, since a misspelled section would otherwise be silently dropped
+	_, err := LoadConfig(configPath)
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	want := []struct {
+		line    int
+		message string
+	}{
+		{7, "unknown section [PRD] (did you mean [PROD]?)"},
+		{10, "unknown section [Production];"},
+		{14, "fp_appEnv cannot be set in environment section [PROD]"},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.File != configPath || p.Line != w.line || !strings.Contains(p.Message, w.message) {
+			t.Errorf("Problem %d = %v; want line %d with %q", i, p, w.line, w.message)
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RotationDateLayout is the layout of <rotationDate> values in vsconfig.xml
+const RotationDateLayout = "2006-01-02"
+
+// SecurityConfig is the parsed content of a vsconfig.xml file
+// It describes the cryptIds, masking patterns, security settings and audit policy
+// that the Voltage library is configured with
+type SecurityConfig struct {
+	Path     string
+	CryptIDs []CryptID
+	Masks    []Mask
+	Security SecurityPolicy
+	Audit    AuditPolicy
+}
+
+// CryptID is a <cryptId> definition
+type CryptID struct {
+	Name         string
+	Algorithm    string // FPE, AES256
+	Key          string
+	Format       string // NUMERIC, ALPHANUMERIC, BASE64, BINARY
+	Description  string
+	KeyVersion   string
+	RotationDate time.Time     // Zero if not set
+	PreviousKeys []PreviousKey // Retired keys still accepted for decryption, from <previousKey> elements
+	Line         int
+}
+
+// PreviousKey is a retired key of a cryptId, declared as <previousKey version="v2.0" key="..."/>
+type PreviousKey struct {
+	Version string
+	Key     string
+}
+
+// Mask is a <mask> definition binding a masking pattern to a cryptId
+type Mask struct {
+	Pattern     string
+	CryptID     string
+	Description string
+	Line        int
+}
+
+// SecurityPolicy holds the <security> settings
+type SecurityPolicy struct {
+	KeyRotationEnabled      bool
+	KeyRotationIntervalDays int
+	AuditLogging            bool
+	ComplianceMode          string
+	FailOnInvalidKey        bool
+}
+
+// AuditPolicy holds the <audit> settings
+type AuditPolicy struct {
+	LogAllOperations bool
+	LogEncryption    bool
+	LogDecryption    bool
+	LogKeyAccess     bool
+}
+
+// securityRootElement is the expected document element of vsconfig.xml
+const securityRootElement = "VoltageSecurityConfiguration"
+
+// Raw XML shapes, decoded before conversion to the typed structs
+type xmlCryptID struct {
+	Name         string           `xml:"name,attr"`
+	Algorithm    string           `xml:"algorithm,attr"`
+	Key          string           `xml:"key,attr"`
+	Format       string           `xml:"format,attr"`
+	Description  string           `xml:"description"`
+	KeyVersion   string           `xml:"keyVersion"`
+	RotationDate string           `xml:"rotationDate"`
+	PreviousKeys []xmlPreviousKey `xml:"previousKey"`
+}
+
+type xmlPreviousKey struct {
+	Version string `xml:"version,attr"`
+	Key     string `xml:"key,attr"`
+}
+
+type xmlMask struct {
+	Pattern     string `xml:"pattern,attr"`
+	CryptID     string `xml:"cryptId,attr"`
+	Description string `xml:"description"`
+}
+
+type xmlSecurity struct {
+	KeyRotationEnabled      string `xml:"keyRotationEnabled"`
+	KeyRotationIntervalDays string `xml:"keyRotationIntervalDays"`
+	AuditLogging            string `xml:"auditLogging"`
+	ComplianceMode          string `xml:"complianceMode"`
+	FailOnInvalidKey        string `xml:"failOnInvalidKey"`
+}
+
+type xmlAudit struct {
+	LogAllOperations string `xml:"logAllOperations"`
+	LogEncryption    string `xml:"logEncryption"`
+	LogDecryption    string `xml:"logDecryption"`
+	LogKeyAccess     string `xml:"logKeyAccess"`
+}
+
+// LoadSecurityConfig reads and parses a vsconfig.xml file
+// Errors are returned as *ConfigError carrying the file name and line number
+func LoadSecurityConfig(path string) (*SecurityConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read security config: %w", err)
+	}
+	return ParseSecurityConfig(path, data)
+}
+
+// ParseSecurityConfig parses vsconfig.xml content; path is only used in error messages
+func ParseSecurityConfig(path string, data []byte) (*SecurityConfig, error) {
+	p := &securityParser{
+		path:    path,
+		dec:     xml.NewDecoder(bytes.NewReader(data)),
+		cfg:     &SecurityConfig{Path: path},
+		cryptID: make(map[string]int),
+	}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.cfg, nil
+}
+
+// securityParser walks the XML token stream so elements can be tied to line numbers
+type securityParser struct {
+	path    string
+	dec     *xml.Decoder
+	cfg     *SecurityConfig
+	cryptID map[string]int // name -> line of first definition
+}
+
+func (p *securityParser) parse() error {
+	rootSeen := false
+	for {
+		tok, err := p.dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return p.syntaxError(err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		line, _ := p.dec.InputPos()
+
+		if !rootSeen {
+			if start.Name.Local != securityRootElement {
+				return p.errorf(line, "", "root element must be <%s>, got <%s>", securityRootElement, start.Name.Local)
+			}
+			rootSeen = true
+			continue
+		}
+
+		switch start.Name.Local {
+		case "cryptId":
+			err = p.parseCryptID(start, line)
+		case "mask":
+			err = p.parseMask(start, line)
+		case "security":
+			err = p.parseSecurity(start, line)
+		case "audit":
+			err = p.parseAudit(start, line)
+		default:
+			// Unknown elements are ignored so newer files remain readable
+			err = p.dec.Skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if !rootSeen {
+		return p.errorf(0, "", "missing <%s> element", securityRootElement)
+	}
+
+	return nil
+}
+
+func (p *securityParser) parseCryptID(start xml.StartElement, line int) error {
+	var raw xmlCryptID
+	if err := p.dec.DecodeElement(&raw, &start); err != nil {
+		return p.syntaxError(err)
+	}
+
+	c := CryptID{
+		Name:        strings.TrimSpace(raw.Name),
+		Algorithm:   strings.ToUpper(strings.TrimSpace(raw.Algorithm)),
+		Key:         raw.Key,
+		Format:      strings.ToUpper(strings.TrimSpace(raw.Format)),
+		Description: strings.TrimSpace(raw.Description),
+		KeyVersion:  strings.TrimSpace(raw.KeyVersion),
+		Line:        line,
+	}
+
+	if c.Name == "" {
+		return p.errorf(line, "cryptId", "name attribute is required")
+	}
+	if first, dup := p.cryptID[c.Name]; dup {
+		return p.errorf(line, "cryptId", "duplicate cryptId %q (first defined on line %d)", c.Name, first)
+	}
+	if c.Algorithm == "" {
+		return p.errorf(line, "cryptId", "cryptId %q: algorithm attribute is required", c.Name)
+	}
+	if c.Format == "" {
+		return p.errorf(line, "cryptId", "cryptId %q: format attribute is required", c.Name)
+	}
+
+	if date := strings.TrimSpace(raw.RotationDate); date != "" {
+		t, err := time.Parse(RotationDateLayout, date)
+		if err != nil {
+			return p.errorf(line, "rotationDate", "cryptId %q: invalid rotationDate %q (expected YYYY-MM-DD)", c.Name, date)
+		}
+		c.RotationDate = t
+	}
+
+	for _, prev := range raw.PreviousKeys {
+		k := PreviousKey{Version: strings.TrimSpace(prev.Version), Key: prev.Key}
+		switch {
+		case c.KeyVersion == "":
+			return p.errorf(line, "previousKey", "cryptId %q: previousKey requires a keyVersion for the current key", c.Name)
+		case k.Version == "" || k.Key == "":
+			return p.errorf(line, "previousKey", "cryptId %q: previousKey needs version and key attributes", c.Name)
+		case c.HasKeyVersion(k.Version):
+			return p.errorf(line, "previousKey", "cryptId %q: key version %q is declared more than once", c.Name, k.Version)
+		}
+		c.PreviousKeys = append(c.PreviousKeys, k)
+	}
+
+	p.cryptID[c.Name] = line
+	p.cfg.CryptIDs = append(p.cfg.CryptIDs, c)
+	return nil
+}
+
+func (p *securityParser) parseMask(start xml.StartElement, line int) error {
+	var raw xmlMask
+	if err := p.dec.DecodeElement(&raw, &start); err != nil {
+		return p.syntaxError(err)
+	}
+
+	m := Mask{
+		Pattern:     raw.Pattern,
+		CryptID:     strings.TrimSpace(raw.CryptID),
+		Description: strings.TrimSpace(raw.Description),
+		Line:        line,
+	}
+
+	if m.Pattern == "" {
+		return p.errorf(line, "mask", "pattern attribute is required")
+	}
+	if m.CryptID == "" {
+		return p.errorf(line, "mask", "cryptId attribute is required")
+	}
+
+	p.cfg.Masks = append(p.cfg.Masks, m)
+	return nil
+}
+
+func (p *securityParser) parseSecurity(start xml.StartElement, line int) error {
+	var raw xmlSecurity
+	if err := p.dec.DecodeElement(&raw, &start); err != nil {
+		return p.syntaxError(err)
+	}
+
+	var err error
+	s := &p.cfg.Security
+	if s.KeyRotationEnabled, err = p.parseBool(line, "keyRotationEnabled", raw.KeyRotationEnabled); err != nil {
+		return err
+	}
+	if s.AuditLogging, err = p.parseBool(line, "auditLogging", raw.AuditLogging); err != nil {
+		return err
+	}
+	if s.FailOnInvalidKey, err = p.parseBool(line, "failOnInvalidKey", raw.FailOnInvalidKey); err != nil {
+		return err
+	}
+	if v := strings.TrimSpace(raw.KeyRotationIntervalDays); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return p.errorf(line, "keyRotationIntervalDays", "expected a non-negative number of days, got %q", v)
+		}
+		s.KeyRotationIntervalDays = days
+	}
+	s.ComplianceMode = strings.TrimSpace(raw.ComplianceMode)
+
+	return nil
+}
+
+func (p *securityParser) parseAudit(start xml.StartElement, line int) error {
+	var raw xmlAudit
+	if err := p.dec.DecodeElement(&raw, &start); err != nil {
+		return p.syntaxError(err)
+	}
+
+	var err error
+	a := &p.cfg.Audit
+	if a.LogAllOperations, err = p.parseBool(line, "logAllOperations", raw.LogAllOperations); err != nil {
+		return err
+	}
+	if a.LogEncryption, err = p.parseBool(line, "logEncryption", raw.LogEncryption); err != nil {
+		return err
+	}
+	if a.LogDecryption, err = p.parseBool(line, "logDecryption", raw.LogDecryption); err != nil {
+		return err
+	}
+	if a.LogKeyAccess, err = p.parseBool(line, "logKeyAccess", raw.LogKeyAccess); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseBool parses an optional boolean element; empty values are false
+func (p *securityParser) parseBool(line int, field, value string) (bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, p.errorf(line, field, "expected true or false, got %q", value)
+	}
+	return b, nil
+}
+
+func (p *securityParser) errorf(line int, field, format string, args ...interface{}) error {
+	return &ConfigError{
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+		File:    p.path,
+		Line:    line,
+	}
+}
+
+// syntaxError converts an encoding/xml error into a positioned *ConfigError
+func (p *securityParser) syntaxError(err error) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return p.errorf(syntaxErr.Line, "", "malformed XML: %s", syntaxErr.Msg)
+	}
+	line, _ := p.dec.InputPos()
+	return p.errorf(line, "", "malformed XML: %v", err)
+}
+
+// CryptID returns the cryptId definition with the given name
+func (s *SecurityConfig) CryptID(name string) (*CryptID, bool) {
+	for i := range s.CryptIDs {
+		if s.CryptIDs[i].Name == name {
+			return &s.CryptIDs[i], true
+		}
+	}
+	return nil, false
+}
+
+// KeyForVersion returns the key of the cryptId with the given version, current or previous
+func (c *CryptID) KeyForVersion(version string) (string, bool) {
+	if version == c.KeyVersion {
+		return c.Key, true
+	}
+	for _, prev := range c.PreviousKeys {
+		if prev.Version == version {
+			return prev.Key, true
+		}
+	}
+	return "", false
+}
+
+// HasKeyVersion reports whether version is the current or a previous key version of the cryptId
+func (c *CryptID) HasKeyVersion(version string) bool {
+	_, ok := c.KeyForVersion(version)
+	return ok
+}
+
+// MaskFor returns the masking pattern registered for the given cryptId
+func (s *SecurityConfig) MaskFor(cryptID string) (*Mask, bool) {
+	for i := range s.Masks {
+		if s.Masks[i].CryptID == cryptID {
+			return &s.Masks[i], true
+		}
+	}
+	return nil, false
+}
+
+// CryptIDNames returns the names of all defined cryptIds in file order
+func (s *SecurityConfig) CryptIDNames() []string {
+	names := make([]string, len(s.CryptIDs))
+	for i, c := range s.CryptIDs {
+		names[i] = c.Name
+	}
+	return names
+}
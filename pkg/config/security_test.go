@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadSecurityConfigSamples(t *testing.T) {
+	for _, env := range []string{"dev", "qa", "prod"} {
+		t.Run(env, func(t *testing.T) {
+			sc, err := LoadSecurityConfig(filepath.Join(env, "vsconfig.xml"))
+			if err != nil {
+				t.Fatalf("Failed to load %s/vsconfig.xml: %v", env, err)
+			}
+			if len(sc.CryptIDs) == 0 {
+				t.Error("Expected cryptIds to be defined")
+			}
+			if len(sc.Masks) != 3 {
+				t.Errorf("Expected 3 masks, got %d", len(sc.Masks))
+			}
+			if !sc.Security.KeyRotationEnabled {
+				t.Error("Expected keyRotationEnabled to be true")
+			}
+		})
+	}
+}
+
+func TestLoadSecurityConfigProd(t *testing.T) {
+	sc, err := LoadSecurityConfig("prod/vsconfig.xml")
+	if err != nil {
+		t.Fatalf("Failed to load prod config: %v", err)
+	}
+
+	ssn, ok := sc.CryptID("SSN_Internal")
+	if !ok {
+		t.Fatal("Expected SSN_Internal to be defined")
+	}
+	if ssn.Algorithm != "FPE" || ssn.Format != "NUMERIC" {
+		t.Errorf("Unexpected SSN_Internal algorithm/format: %s/%s", ssn.Algorithm, ssn.Format)
+	}
+	if ssn.KeyVersion != "v2.1" {
+		t.Errorf("Expected keyVersion v2.1, got %s", ssn.KeyVersion)
+	}
+	if !ssn.RotationDate.Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected rotationDate %v", ssn.RotationDate)
+	}
+	if ssn.Line != 11 {
+		t.Errorf("Expected SSN_Internal on line 11, got %d", ssn.Line)
+	}
+
+	mask, ok := sc.MaskFor("CCN_Internal")
+	if !ok || mask.Pattern != "XXXX-XXXX-XXXX-####" {
+		t.Errorf("Unexpected CCN_Internal mask: %+v", mask)
+	}
+	if _, ok := sc.MaskFor("TEXT_Internal"); ok {
+		t.Error("TEXT_Internal should not have a mask")
+	}
+
+	if sc.Security.KeyRotationIntervalDays != 60 {
+		t.Errorf("Expected keyRotationIntervalDays 60, got %d", sc.Security.KeyRotationIntervalDays)
+	}
+	if sc.Security.ComplianceMode != "PCI-DSS" {
+		t.Errorf("Expected complianceMode PCI-DSS, got %s", sc.Security.ComplianceMode)
+	}
+
+	expectedAudit := AuditPolicy{LogAllOperations: true, LogEncryption: true, LogDecryption: true, LogKeyAccess: true}
+	if sc.Audit != expectedAudit {
+		t.Errorf("Unexpected audit policy: %+v", sc.Audit)
+	}
+
+	names := strings.Join(sc.CryptIDNames(), ",")
+	if names != "SSN_Internal,CCN_Internal,EMAIL_Internal,TEXT_Internal,BINARY_Internal" {
+		t.Errorf("Unexpected cryptId names: %s", names)
+	}
+}
+
+func TestParseSecurityConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		xml   string
+		line  int
+		field string
+	}{
+		{
+			name: "Malformed XML",
+			xml:  "<VoltageSecurityConfiguration>\n  <cryptId name=\"A\">\n</VoltageSecurityConfiguration>",
+			line: 3,
+		},
+		{
+			name: "Wrong root element",
+			xml:  "<?xml version=\"1.0\"?>\n<Config/>",
+			line: 2,
+		},
+		{
+			name:  "Missing cryptId name",
+			xml:   "<VoltageSecurityConfiguration>\n\n  <cryptId algorithm=\"FPE\" format=\"NUMERIC\"/>\n</VoltageSecurityConfiguration>",
+			line:  3,
+			field: "cryptId",
+		},
+		{
+			name:  "Duplicate cryptId",
+			xml:   "<VoltageSecurityConfiguration>\n  <cryptId name=\"A\" algorithm=\"FPE\" format=\"NUMERIC\"/>\n  <cryptId name=\"A\" algorithm=\"FPE\" format=\"NUMERIC\"/>\n</VoltageSecurityConfiguration>",
+			line:  3,
+			field: "cryptId",
+		},
+		{
+			name:  "Invalid rotationDate",
+			xml:   "<VoltageSecurityConfiguration>\n  <cryptId name=\"A\" algorithm=\"FPE\" format=\"NUMERIC\">\n    <rotationDate>12/01/2025</rotationDate>\n  </cryptId>\n</VoltageSecurityConfiguration>",
+			line:  2,
+			field: "rotationDate",
+		},
+		{
+			name:  "Invalid boolean",
+			xml:   "<VoltageSecurityConfiguration>\n  <audit>\n    <logEncryption>yes</logEncryption>\n  </audit>\n</VoltageSecurityConfiguration>",
+			line:  2,
+			field: "logEncryption",
+		},
+		{
+			name:  "previousKey without current keyVersion",
+			xml:   "<VoltageSecurityConfiguration>\n  <cryptId name=\"A\" algorithm=\"FPE\" format=\"NUMERIC\">\n    <previousKey version=\"v1\" key=\"k1\"/>\n  </cryptId>\n</VoltageSecurityConfiguration>",
+			line:  2,
+			field: "previousKey",
+		},
+		{
+			name:  "Duplicate key version",
+			xml:   "<VoltageSecurityConfiguration>\n  <cryptId name=\"A\" algorithm=\"FPE\" format=\"NUMERIC\">\n    <keyVersion>v2</keyVersion>\n    <previousKey version=\"v2\" key=\"k1\"/>\n  </cryptId>\n</VoltageSecurityConfiguration>",
+			line:  2,
+			field: "previousKey",
+		},
+		{
+			name:  "Mask without pattern",
+			xml:   "<VoltageSecurityConfiguration>\n  <mask cryptId=\"A\"/>\n</VoltageSecurityConfiguration>",
+			line:  2,
+			field: "mask",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSecurityConfig("test.xml", []byte(tt.xml))
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("Expected *ConfigError, got %v", err)
+			}
+			if cfgErr.File != "test.xml" {
+				t.Errorf("Expected File test.xml, got %s", cfgErr.File)
+			}
+			if cfgErr.Line != tt.line {
+				t.Errorf("Expected line %d, got %d (%v)", tt.line, cfgErr.Line, err)
+			}
+			if cfgErr.Field != tt.field {
+				t.Errorf("Expected field %q, got %q", tt.field, cfgErr.Field)
+			}
+			if !strings.HasPrefix(err.Error(), "test.xml:") {
+				t.Errorf("Expected error to start with file position, got %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestPreviousKeys(t *testing.T) {
+	xml := `<VoltageSecurityConfiguration>
+  <cryptId name="A" algorithm="FPE" key="k3" format="NUMERIC">
+    <keyVersion>v3</keyVersion>
+    <previousKey version="v2" key="k2"/>
+    <previousKey version="v1" key="k1"/>
+  </cryptId>
+</VoltageSecurityConfiguration>`
+
+	sc, err := ParseSecurityConfig("test.xml", []byte(xml))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	a, _ := sc.CryptID("A")
+	if len(a.PreviousKeys) != 2 || a.PreviousKeys[0] != (PreviousKey{Version: "v2", Key: "k2"}) {
+		t.Errorf("Unexpected previous keys: %+v", a.PreviousKeys)
+	}
+
+	for version, want := range map[string]string{"v3": "k3", "v2": "k2", "v1": "k1"} {
+		if key, ok := a.KeyForVersion(version); !ok || key != want {
+			t.Errorf("KeyForVersion(%s) = %s, %v; want %s", version, key, ok, want)
+		}
+	}
+	if a.HasKeyVersion("v0") {
+		t.Error("v0 should not be a known key version")
+	}
+}
+
+func TestLoadSecurityConfigMissingFile(t *testing.T) {
+	if _, err := LoadSecurityConfig("/nonexistent/vsconfig.xml"); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
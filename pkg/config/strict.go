@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cfgLine is a non-blank, non-comment .cfg line that is neither a section header nor key=value
+type cfgLine struct {
+	Line int
+	Text string
+}
+
+// checkStrict reports every problem WithStrictParsing rejects: malformed lines, unknown keys,
+// keys set twice for the same environment and values of the wrong type
+// Sections of every environment are checked, not only the selected one
+func checkStrict(filePath string, f cfgFile) ValidationErrors {
+	var problems ValidationErrors
+	for _, m := range f.malformed {
+		problems = append(problems, &ConfigError{
+			Message: fmt.Sprintf("malformed line %q (expected key=value or [section])", m.Text),
+			File:    filePath,
+			Line:    m.Line,
+		})
+	}
+
+	type scopedKey struct{ env, key string }
+	seen := make(map[scopedKey]int)
+	for _, e := range f.entries {
+		field, ok := fileKeys[e.Key]
+		if !ok {
+			message := fmt.Sprintf("unknown key %q", e.Key)
+			if suggestion := suggestKey(e.Key); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			problems = append(problems, &ConfigError{Field: e.Key, Message: message, File: filePath, Line: e.Line})
+			continue
+		}
+
+		scope := scopedKey{environmentSection(e.Section), e.Key}
+		if first, ok := seen[scope]; ok {
+			problems = append(problems, &ConfigError{
+				Field:   field,
+				Message: fmt.Sprintf("duplicate key %q (first set on line %d)", e.Key, first),
+				File:    filePath,
+				Line:    e.Line,
+			})
+		} else {
+			seen[scope] = e.Line
+		}
+
+		if message := checkValue(e.Key, field, e.Value); message != "" {
+			problems = append(problems, &ConfigError{Field: field, Message: message, File: filePath, Line: e.Line})
+		}
+	}
+	return problems
+}
+
+// checkValue returns why value cannot be assigned to field, or "" if it can
+func checkValue(key, field, value string) string {
+	f, _ := reflect.TypeOf(Config{}).FieldByName(field)
+	switch f.Type.Kind() {
+	case reflect.Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Sprintf("%s must be a whole number (got: %q)", key, value)
+		}
+	case reflect.Bool:
+		if v := strings.ToLower(value); v != "true" && v != "false" {
+			return fmt.Sprintf("%s must be true or false (got: %q)", key, value)
+		}
+	}
+	return ""
+}
+
+// suggestKey returns the known key closest to key, or "" if none is close enough to be a typo
+func suggestKey(key string) string {
+	keys := make([]string, 0, len(fileKeys))
+	for k := range fileKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return suggest(key, keys)
+}
+
+// suggest returns the candidate closest to name, ignoring case, or "" if none is close enough to be a typo
+func suggest(name string, candidates []string) string {
+	best, bestDistance := "", len(name)/3+2
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(c)); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigStrictParsing(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "voltageprotector.cfg")
+	configContent := `[common]
+fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=secret
+fp_networkTimout=30
+LogLevel=debug
+fp_appName=Other
+this line has no equals sign
+
+[PROD]
+fp_disableCRLChecking=yes
+fp_networkTimeout=30
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// Without strict parsing the mistakes are ignored
+	if _, err := LoadConfig(configPath); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	_, err := LoadConfig(configPath, WithStrictParsing())
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Expected ValidationErrors, got %v", err)
+	}
+
+	want := []struct {
+		line    int
+		message string
+	}{
+		{9, `malformed line "this line has no equals sign"`},
+		{6, `unknown key "fp_networkTimout" (did you mean "fp_networkTimeout"?)`},
+		{7, `LogLevel must be a whole number (got: "debug")`},
+		{8, `duplicate key "fp_appName" (first set on line 2)`},
+		{12, `fp_disableCRLChecking must be true or false (got: "yes")`},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems, got %d: %v", len(want), len(problems), problems)
+	}
+	for i, w := range want {
+		p := problems[i]
+		if p.File != configPath || p.Line != w.line || !strings.Contains(p.Message, w.message) {
+			t.Errorf("Problem %d = %v; want line %d with %q", i, p, w.line, w.message)
+		}
+	}
+	if !strings.Contains(err.Error(), configPath+":6: ") {
+		t.Errorf("Error should carry file:line positions: %v", err)
+	}
+}
+
+func TestLoadConfigStrictParsingAcceptsValid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "voltageprotector.cfg")
+	configContent := `[ProtectorConfig]
+fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=secret
+fp_disableCRLChecking=TRUE
+
+[DEV]
+fp_networkTimeout=15
+
+[PROD]
+fp_networkTimeout=30
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath, WithStrictParsing())
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.NetworkTimeout != 15 || !cfg.DisableCRLChecking {
+		t.Errorf("Unexpected configuration: %+v", cfg)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := map[string]string{
+		"fp_networkTimout":        "fp_networkTimeout",
+		"loglevel":                "LogLevel",
+		"fp_default_sharedsecret": "fp_default_sharedSecret",
+		"XMLConfg":                "XMLConfig",
+		"completely_unrelated":    "",
+	}
+	for key, want := range tests {
+		if got := suggestKey(key); got != want {
+			t.Errorf("suggestKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
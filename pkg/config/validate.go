@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ValidationErrors collects every problem found by ValidateDeep
+type ValidationErrors []*ConfigError
+
+// Error joins the individual error messages
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, err := range v {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d configuration error(s): %s", len(v), strings.Join(messages, "; "))
+}
+
+// Unwrap exposes the individual errors to errors.Is and errors.As
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, err := range v {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ValidateDeep performs Validate and additionally loads the referenced vsconfig.xml,
+// verifying that it is readable and consistent with the .cfg settings:
+//   - DefaultCryptID must name a <cryptId> defined in the XML
+//   - every <mask cryptId=...> must reference a defined <cryptId>
+//
+// All problems are reported together as ValidationErrors
+func (c *Config) ValidateDeep() error {
+	problems := ValidationErrors(c.fieldErrors())
+
+	if c.XMLConfigPath == "" {
+		problems = append(problems, &ConfigError{
+			Field:   "XMLConfigPath",
+			Message: "XMLConfig is required for deep validation (set XMLConfig or FP_XMLCONFIG)",
+			File:    c.ConfigFilePath,
+		})
+		return problems.orNil()
+	}
+
+	security, err := LoadSecurityConfig(c.XMLConfigPath)
+	if err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			problems = append(problems, cfgErr)
+		} else {
+			problems = append(problems, &ConfigError{
+				Field:   "XMLConfigPath",
+				Message: fmt.Sprintf("cannot read %s: %v", c.XMLConfigPath, errors.Unwrap(err)),
+				File:    c.ConfigFilePath,
+			})
+		}
+		return problems.orNil()
+	}
+
+	problems = append(problems, c.crossCheck(security)...)
+	return problems.orNil()
+}
+
+// crossCheck reports inconsistencies between the configuration and the parsed security config
+func (c *Config) crossCheck(security *SecurityConfig) []*ConfigError {
+	var problems []*ConfigError
+
+	if c.DefaultCryptID != "" {
+		if _, ok := security.CryptID(c.DefaultCryptID); !ok {
+			problems = append(problems, &ConfigError{
+				Field: "DefaultCryptID",
+				Message: fmt.Sprintf("DefaultCryptId %q is not defined in %s (defined: %s)",
+					c.DefaultCryptID, security.Path, strings.Join(security.CryptIDNames(), ", ")),
+				File: c.ConfigFilePath,
+			})
+		}
+	}
+
+	for _, mask := range security.Masks {
+		if _, ok := security.CryptID(mask.CryptID); !ok {
+			problems = append(problems, &ConfigError{
+				Field:   "mask",
+				Message: fmt.Sprintf("mask %q references undefined cryptId %q", mask.Pattern, mask.CryptID),
+				File:    security.Path,
+				Line:    mask.Line,
+			})
+		}
+	}
+
+	return problems
+}
+
+// orNil returns nil when there are no problems so callers can compare against nil
+func (v ValidationErrors) orNil() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func validDeepConfig(xmlPath string) *Config {
+	return &Config{
+		AppName:         "TestApp",
+		AppVersion:      "1.0.0",
+		AppEnv:          "DEV",
+		DEKSharedSecret: "secret",
+		XMLConfigPath:   xmlPath,
+		DefaultCryptID:  "SSN_Internal",
+		ConfigFilePath:  "test.cfg",
+	}
+}
+
+func TestValidateDeepSamples(t *testing.T) {
+	for _, env := range []string{"dev", "qa", "prod"} {
+		t.Run(env, func(t *testing.T) {
+			cfg := validDeepConfig(filepath.Join(env, "vsconfig.xml"))
+			if err := cfg.ValidateDeep(); err != nil {
+				t.Errorf("Expected %s config to pass deep validation, got: %v", env, err)
+			}
+		})
+	}
+}
+
+func TestValidateDeepReportsAllProblems(t *testing.T) {
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	xmlContent := `<VoltageSecurityConfiguration>
+    <cryptId name="SSN_Internal" algorithm="FPE" key="k" format="NUMERIC"/>
+    <mask pattern="XXX-XX-####" cryptId="SSN_Internal"/>
+    <mask pattern="XXXX-####" cryptId="CCN_Missing"/>
+</VoltageSecurityConfiguration>
+`
+	if err := os.WriteFile(xmlPath, []byte(xmlContent), 0644); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+
+	cfg := validDeepConfig(xmlPath)
+	cfg.AppName = ""
+	cfg.DefaultCryptID = "UNKNOWN"
+
+	err := cfg.ValidateDeep()
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("Expected ValidationErrors, got: %v", err)
+	}
+
+	fields := map[string]*ConfigError{}
+	for _, p := range problems {
+		fields[p.Field] = p
+	}
+	if len(problems) != 3 {
+		t.Errorf("Expected 3 problems, got %d: %v", len(problems), err)
+	}
+	for _, field := range []string{"AppName", "DefaultCryptID", "mask"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("Expected a problem for field %s, got: %v", field, err)
+		}
+	}
+	if mask := fields["mask"]; mask != nil && (mask.File != xmlPath || mask.Line != 4) {
+		t.Errorf("Expected mask problem at %s:4, got %s:%d", xmlPath, mask.File, mask.Line)
+	}
+
+	// Individual errors are reachable through errors.As
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Error("Expected errors.As to find a *ConfigError")
+	}
+}
+
+func TestValidateDeepMissingXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		xmlPath string
+	}{
+		{"No XMLConfig", ""},
+		{"Unreadable XMLConfig", "/nonexistent/vsconfig.xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validDeepConfig(tt.xmlPath).ValidateDeep()
+			var problems ValidationErrors
+			if !errors.As(err, &problems) || len(problems) != 1 {
+				t.Fatalf("Expected a single problem, got: %v", err)
+			}
+			if problems[0].Field != "XMLConfigPath" {
+				t.Errorf("Expected XMLConfigPath problem, got %s", problems[0].Field)
+			}
+		})
+	}
+}
+
+func TestLoadConfigWithDeepValidation(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "test.cfg")
+
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=secret
+XMLConfig=` + filepath.Join(tmpDir, "missing.xml") + `
+DefaultCryptId=SSN_Internal
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("Failed to write test config: %v", err)
+	}
+
+	// Plain validation does not look at the XML file
+	if _, err := LoadConfig(configPath); err != nil {
+		t.Fatalf("Expected LoadConfig to succeed without deep validation: %v", err)
+	}
+
+	if _, err := LoadConfig(configPath, WithDeepValidation()); err == nil {
+		t.Error("Expected deep validation to fail for missing XML file")
+	}
+}
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by the default vault:// provider
+const (
+	EnvVaultAddr      = "VAULT_ADDR"
+	EnvVaultToken     = "VAULT_TOKEN"
+	EnvVaultNamespace = "VAULT_NAMESPACE"
+	EnvVaultRoleID    = "VAULT_ROLE_ID"
+	EnvVaultSecretID  = "VAULT_SECRET_ID"
+)
+
+// DefaultVaultCacheTTL is how long a VaultProvider reuses a secret it has read
+// LoadConfig calls within the TTL get the cached value even if the secret changed in Vault;
+// Watcher reloads and other resolutions under WithoutSecretCache read it again
+const DefaultVaultCacheTTL = 5 * time.Minute
+
+// VaultConfig configures a VaultProvider
+// Token auth is used when Token is set, AppRole auth when RoleID and SecretID are
+type VaultConfig struct {
+	Address   string // Vault URL, such as https://vault.example.com:8200
+	Namespace string // Sent as X-Vault-Namespace when set
+	Token     string
+
+	RoleID       string
+	SecretID     string
+	AppRoleMount string // Auth mount of AppRole, "approle" if empty
+
+	CacheTTL   time.Duration // DefaultVaultCacheTTL if zero; negative disables caching
+	HTTPClient *http.Client  // http.DefaultClient if nil
+}
+
+// VaultConfigFromEnv reads a VaultConfig from the standard Vault environment variables
+func VaultConfigFromEnv() VaultConfig {
+	return VaultConfig{
+		Address:   os.Getenv(EnvVaultAddr),
+		Namespace: os.Getenv(EnvVaultNamespace),
+		Token:     os.Getenv(EnvVaultToken),
+		RoleID:    os.Getenv(EnvVaultRoleID),
+		SecretID:  os.Getenv(EnvVaultSecretID),
+	}
+}
+
+// VaultProvider is a SecretProvider reading Vault KV secrets
+// References have the form "vault://<path>#<field>", such as
+// vault://secret/data/voltage#dek_shared_secret for KV v2; KV v1 paths work too
+//
+// Token renewal is lazy: when a secret is read and two thirds of the token's TTL have
+// passed, the token is renewed first or, with AppRole auth, replaced by a fresh login.
+// Nothing runs in the background, so a static token with a TTL shorter than the gaps
+// between reads expires; use AppRole or a periodic token for long-lived processes
+type VaultProvider struct {
+	cfg  VaultConfig
+	now  func() time.Time
+	http *http.Client
+
+	mu    sync.Mutex
+	token vaultToken
+	cache map[string]vaultSecret // By path
+}
+
+type vaultToken struct {
+	value     string
+	renewable bool
+	ttl       time.Duration
+	renewAt   time.Time // Zero if the token does not expire
+	checked   bool      // A static token's TTL has been looked up
+}
+
+type vaultSecret struct {
+	data    map[string]any
+	expires time.Time
+}
+
+// NewVaultProvider returns a provider for cfg; it does not contact Vault until a secret is read
+func NewVaultProvider(cfg VaultConfig) (*VaultProvider, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault address is required (set %s)", EnvVaultAddr)
+	}
+	hasAppRole := cfg.RoleID != "" && cfg.SecretID != ""
+	if cfg.Token == "" && !hasAppRole {
+		return nil, fmt.Errorf("vault credentials are required (set %s, or %s and %s)", EnvVaultToken, EnvVaultRoleID, EnvVaultSecretID)
+	}
+	if cfg.AppRoleMount == "" {
+		cfg.AppRoleMount = "approle"
+	}
+	if cfg.CacheTTL == 0 {
+		cfg.CacheTTL = DefaultVaultCacheTTL
+	}
+	cfg.Address = strings.TrimSuffix(cfg.Address, "/")
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &VaultProvider{
+		cfg:   cfg,
+		now:   time.Now,
+		http:  client,
+		cache: make(map[string]vaultSecret),
+	}, nil
+}
+
+// Secret returns field of the secret at path, for a reference "path#field"
+func (v *VaultProvider) Secret(ctx context.Context, ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || field == "" {
+		return "", fmt.Errorf("vault reference must have the form vault://<path>#<field>")
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	data, err := v.readLocked(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault secret %s has no field %s", path, field)
+	}
+	secret, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("vault secret %s field %s is not a string", path, field)
+	}
+	return secret, nil
+}
+
+// readLocked returns the data of the secret at path, from the cache if it is fresh
+// and ctx does not come from WithoutSecretCache
+func (v *VaultProvider) readLocked(ctx context.Context, path string) (map[string]any, error) {
+	if cached, ok := v.cache[path]; ok && v.now().Before(cached.expires) && !skipSecretCache(ctx) {
+		return cached.data, nil
+	}
+
+	var resp struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := v.authorizedLocked(ctx, http.MethodGet, "/v1/"+path, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	// KV v2 nests the secret under data.data; KV v1 returns it as data
+	data := resp.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("vault secret %s has no data", path)
+	}
+
+	if v.cfg.CacheTTL > 0 {
+		v.cache[path] = vaultSecret{data: data, expires: v.now().Add(v.cfg.CacheTTL)}
+	}
+	return data, nil
+}
+
+// authorizedLocked sends a request with a valid client token
+// With AppRole auth a rejected token is replaced by a fresh login and the request repeated once
+func (v *VaultProvider) authorizedLocked(ctx context.Context, method, path string, body, out any) error {
+	token, err := v.tokenLocked(ctx)
+	if err != nil {
+		return err
+	}
+	err = v.do(ctx, method, path, token, body, out)
+	if isVaultForbidden(err) && v.usesAppRole() {
+		v.token = vaultToken{}
+		if token, err = v.tokenLocked(ctx); err != nil {
+			return err
+		}
+		err = v.do(ctx, method, path, token, body, out)
+	}
+	return err
+}
+
+// tokenLocked returns the client token, logging in or renewing it first if needed
+func (v *VaultProvider) tokenLocked(ctx context.Context) (string, error) {
+	switch {
+	case v.token.value == "" && v.usesAppRole():
+		if err := v.loginLocked(ctx); err != nil {
+			return "", err
+		}
+	case v.token.value == "":
+		v.token = vaultToken{value: v.cfg.Token}
+	}
+
+	if !v.token.checked {
+		// A static token's TTL is unknown until looked up; tokens that may not look
+		// themselves up are used as they are
+		v.token.checked = true
+		var resp struct {
+			Data struct {
+				TTL       int  `json:"ttl"`
+				Renewable bool `json:"renewable"`
+			} `json:"data"`
+		}
+		if err := v.do(ctx, http.MethodGet, "/v1/auth/token/lookup-self", v.token.value, nil, &resp); err == nil {
+			v.setLeaseLocked(resp.Data.TTL, resp.Data.Renewable)
+		}
+	}
+
+	if v.token.renewAt.IsZero() || v.now().Before(v.token.renewAt) {
+		return v.token.value, nil
+	}
+
+	if v.token.renewable {
+		if err := v.renewLocked(ctx); err == nil {
+			return v.token.value, nil
+		} else if !v.usesAppRole() {
+			return "", err
+		}
+	}
+	if !v.usesAppRole() {
+		// Nothing more can be done for a static token; Vault has the final say
+		return v.token.value, nil
+	}
+	if err := v.loginLocked(ctx); err != nil {
+		return "", err
+	}
+	return v.token.value, nil
+}
+
+func (v *VaultProvider) usesAppRole() bool {
+	return v.cfg.RoleID != "" && v.cfg.SecretID != ""
+}
+
+// vaultAuth is the auth block of login and renew responses
+type vaultAuth struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+func (v *VaultProvider) loginLocked(ctx context.Context) error {
+	body := map[string]string{"role_id": v.cfg.RoleID, "secret_id": v.cfg.SecretID}
+	var resp vaultAuth
+	if err := v.do(ctx, http.MethodPost, "/v1/auth/"+v.cfg.AppRoleMount+"/login", "", body, &resp); err != nil {
+		return fmt.Errorf("vault AppRole login failed: %w", err)
+	}
+	if resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return fmt.Errorf("vault AppRole login returned no token")
+	}
+	v.token = vaultToken{value: resp.Auth.ClientToken, checked: true}
+	v.setLeaseLocked(resp.Auth.LeaseDuration, resp.Auth.Renewable)
+	return nil
+}
+
+func (v *VaultProvider) renewLocked(ctx context.Context) error {
+	body := map[string]string{"increment": fmt.Sprintf("%ds", int(v.token.ttl.Seconds()))}
+	var resp vaultAuth
+	if err := v.do(ctx, http.MethodPost, "/v1/auth/token/renew-self", v.token.value, body, &resp); err != nil {
+		return fmt.Errorf("vault token renewal failed: %w", err)
+	}
+	if resp.Auth == nil {
+		return fmt.Errorf("vault token renewal returned no lease")
+	}
+	v.setLeaseLocked(resp.Auth.LeaseDuration, resp.Auth.Renewable)
+	return nil
+}
+
+// setLeaseLocked schedules renewal after two thirds of a ttl given in seconds; 0 means no expiry
+func (v *VaultProvider) setLeaseLocked(ttl int, renewable bool) {
+	v.token.renewable = renewable
+	v.token.ttl = time.Duration(ttl) * time.Second
+	v.token.renewAt = time.Time{}
+	if ttl > 0 {
+		v.token.renewAt = v.now().Add(v.token.ttl * 2 / 3)
+	}
+}
+
+// vaultError is a non-2xx Vault response
+type vaultError struct {
+	status int
+	errors []string
+}
+
+func (e *vaultError) Error() string {
+	if len(e.errors) == 0 {
+		return fmt.Sprintf("vault returned %d", e.status)
+	}
+	return fmt.Sprintf("vault returned %d: %s", e.status, strings.Join(e.errors, "; "))
+}
+
+func isVaultForbidden(err error) bool {
+	vErr, ok := err.(*vaultError)
+	return ok && vErr.status == http.StatusForbidden
+}
+
+// do sends one request to Vault and decodes the JSON response into out
+func (v *VaultProvider) do(ctx context.Context, method, path, token string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, v.cfg.Address+path, reader)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var vaultErrs struct {
+			Errors []string `json:"errors"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&vaultErrs)
+		return &vaultError{status: resp.StatusCode, errors: vaultErrs.Errors}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid vault response: %w", err)
+	}
+	return nil
+}
+
+// defaultVault is the vault:// provider of every NewSecretResolver, so that its token
+// and cache last across LoadConfig calls and Watcher reloads instead of logging in each time
+var defaultVault = &envVaultProvider{}
+
+// envVaultProvider is the default vault:// provider
+// It is configured from the environment on first use, so resolvers that never
+// see a vault:// reference do not need Vault settings, and again when the
+// environment changes, such as after a new VAULT_SECRET_ID is set
+type envVaultProvider struct {
+	mu       sync.Mutex
+	cfg      VaultConfig
+	provider *VaultProvider
+}
+
+func (e *envVaultProvider) Secret(ctx context.Context, ref string) (string, error) {
+	provider, err := e.current()
+	if err != nil {
+		return "", err
+	}
+	return provider.Secret(ctx, ref)
+}
+
+// current returns the provider for the Vault settings in the environment
+func (e *envVaultProvider) current() (*VaultProvider, error) {
+	cfg := VaultConfigFromEnv()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.provider == nil || cfg != e.cfg {
+		provider, err := NewVaultProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		e.provider, e.cfg = provider, cfg
+	}
+	return e.provider, nil
+}
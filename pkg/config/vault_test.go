@@ -0,0 +1,334 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVault is an in-memory Vault serving KV v2 secrets, token and AppRole auth
+type fakeVault struct {
+	mu       sync.Mutex
+	secrets  map[string]map[string]any // KV v2 data path -> fields
+	tokens   map[string]bool           // Valid client tokens
+	ttl      int                       // Lease of issued tokens in seconds
+	roleID   string
+	secretID string
+	issued   int
+	requests map[string]int // By method and path
+}
+
+func newFakeVault(t *testing.T) (*fakeVault, *httptest.Server) {
+	t.Helper()
+	fv := &fakeVault{
+		secrets: map[string]map[string]any{
+			"secret/data/voltage": {"dek_shared_secret": "vault-dek", "kek_passphrase": "vault-kek", "port": 8200},
+		},
+		tokens:   map[string]bool{"root-token": true},
+		ttl:      60,
+		roleID:   "vlock-role",
+		secretID: "vlock-secret",
+		requests: make(map[string]int),
+	}
+	server := httptest.NewServer(fv)
+	t.Cleanup(server.Close)
+	return fv, server
+}
+
+func (fv *fakeVault) count(method, path string) int {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	return fv.requests[method+" "+path]
+}
+
+func (fv *fakeVault) revokeAll() {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	fv.tokens = make(map[string]bool)
+}
+
+func (fv *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	fv.requests[r.Method+" "+r.URL.Path]++
+
+	reply := func(status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+	denied := func() { reply(http.StatusForbidden, map[string]any{"errors": []string{"permission denied"}}) }
+	issue := func() {
+		fv.issued++
+		token := "approle-token-" + string(rune('a'+fv.issued))
+		fv.tokens[token] = true
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{
+			"client_token": token, "lease_duration": fv.ttl, "renewable": true,
+		}})
+	}
+
+	if r.URL.Path == "/v1/auth/approle/login" {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != fv.roleID || body["secret_id"] != fv.secretID {
+			denied()
+			return
+		}
+		issue()
+		return
+	}
+
+	if !fv.tokens[r.Header.Get("X-Vault-Token")] {
+		denied()
+		return
+	}
+	switch path := strings.TrimPrefix(r.URL.Path, "/v1/"); {
+	case path == "auth/token/lookup-self":
+		reply(http.StatusOK, map[string]any{"data": map[string]any{"ttl": 0, "renewable": false}})
+	case path == "auth/token/renew-self":
+		reply(http.StatusOK, map[string]any{"auth": map[string]any{
+			"client_token": r.Header.Get("X-Vault-Token"), "lease_duration": fv.ttl, "renewable": true,
+		}})
+	case fv.secrets[path] != nil:
+		reply(http.StatusOK, map[string]any{"data": map[string]any{
+			"data":     fv.secrets[path],
+			"metadata": map[string]any{"version": 1},
+		}})
+	default:
+		reply(http.StatusNotFound, map[string]any{"errors": []string{}})
+	}
+}
+
+func TestVaultProviderTokenAuth(t *testing.T) {
+	fv, server := newFakeVault(t)
+	provider, err := NewVaultProvider(VaultConfig{Address: server.URL, Token: "root-token"})
+	if err != nil {
+		t.Fatalf("NewVaultProvider failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for ref, want := range map[string]string{
+		"secret/data/voltage#dek_shared_secret": "vault-dek",
+		"secret/data/voltage#kek_passphrase":    "vault-kek",
+	} {
+		got, err := provider.Secret(ctx, ref)
+		if err != nil || got != want {
+			t.Errorf("Secret(%q) = %q, %v; want %q", ref, got, err, want)
+		}
+	}
+	if n := fv.count(http.MethodGet, "/v1/secret/data/voltage"); n != 1 {
+		t.Errorf("Expected one KV read thanks to caching, got %d", n)
+	}
+
+	for _, ref := range []string{
+		"secret/data/voltage#missing",
+		"secret/data/voltage#port",
+		"secret/data/other#dek",
+		"secret/data/voltage",
+	} {
+		if _, err := provider.Secret(ctx, ref); err == nil {
+			t.Errorf("Secret(%q) should fail", ref)
+		}
+	}
+}
+
+func TestVaultProviderAppRoleRenewal(t *testing.T) {
+	fv, server := newFakeVault(t)
+	provider, err := NewVaultProvider(VaultConfig{
+		Address:  server.URL,
+		RoleID:   "vlock-role",
+		SecretID: "vlock-secret",
+		CacheTTL: -1,
+	})
+	if err != nil {
+		t.Fatalf("NewVaultProvider failed: %v", err)
+	}
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	ctx := context.Background()
+	ref := "secret/data/voltage#dek_shared_secret"
+
+	if got, err := provider.Secret(ctx, ref); err != nil || got != "vault-dek" {
+		t.Fatalf("Secret = %q, %v", got, err)
+	}
+	if fv.count(http.MethodPost, "/v1/auth/approle/login") != 1 {
+		t.Fatal("Expected an AppRole login")
+	}
+
+	// Two thirds into the lease the token is renewed rather than replaced
+	now = now.Add(45 * time.Second)
+	if _, err := provider.Secret(ctx, ref); err != nil {
+		t.Fatalf("Secret after renewal point failed: %v", err)
+	}
+	if fv.count(http.MethodPost, "/v1/auth/token/renew-self") != 1 || fv.count(http.MethodPost, "/v1/auth/approle/login") != 1 {
+		t.Error("Expected the token to be renewed without a new login")
+	}
+
+	// A revoked token leads to a fresh login
+	fv.revokeAll()
+	if _, err := provider.Secret(ctx, ref); err != nil {
+		t.Fatalf("Secret after revocation failed: %v", err)
+	}
+	if fv.count(http.MethodPost, "/v1/auth/approle/login") != 2 {
+		t.Error("Expected a second AppRole login after the token was revoked")
+	}
+}
+
+func TestVaultProviderBadCredentials(t *testing.T) {
+	_, server := newFakeVault(t)
+	ctx := context.Background()
+
+	provider, _ := NewVaultProvider(VaultConfig{Address: server.URL, RoleID: "vlock-role", SecretID: "wrong"})
+	if _, err := provider.Secret(ctx, "secret/data/voltage#dek_shared_secret"); err == nil || !strings.Contains(err.Error(), "login") {
+		t.Errorf("Expected a login failure, got %v", err)
+	}
+
+	provider, _ = NewVaultProvider(VaultConfig{Address: server.URL, Token: "bad-token"})
+	if _, err := provider.Secret(ctx, "secret/data/voltage#dek_shared_secret"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected a permission error, got %v", err)
+	}
+
+	if _, err := NewVaultProvider(VaultConfig{Address: server.URL}); err == nil {
+		t.Error("Expected credentials to be required")
+	}
+	if _, err := NewVaultProvider(VaultConfig{Token: "root-token"}); err == nil {
+		t.Error("Expected an address to be required")
+	}
+}
+
+func TestLoadConfigResolvesVaultSecrets(t *testing.T) {
+	_, server := newFakeVault(t)
+	t.Setenv(EnvVaultAddr, server.URL)
+	t.Setenv(EnvVaultToken, "root-token")
+
+	configPath := filepath.Join(t.TempDir(), "test.cfg")
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_kek_certPassphrase=vault://secret/data/voltage#kek_passphrase
+fp_default_sharedSecret=vault://secret/data/voltage#dek_shared_secret
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.DEKSharedSecret != "vault-dek" || cfg.KEKCertPassphrase != "vault-kek" {
+		t.Errorf("Vault secrets not resolved: DEK %q, KEK %q", cfg.DEKSharedSecret, cfg.KEKCertPassphrase)
+	}
+
+	t.Setenv(EnvVaultAddr, "")
+	if _, err := LoadConfig(configPath); err == nil || !strings.Contains(err.Error(), EnvVaultAddr) {
+		t.Errorf("Expected an error naming %s, got %v", EnvVaultAddr, err)
+	}
+}
+
+func TestLoadConfigSharesVaultProvider(t *testing.T) {
+	fv, server := newFakeVault(t)
+	t.Setenv(EnvVaultAddr, server.URL)
+	t.Setenv(EnvVaultRoleID, "vlock-role")
+	t.Setenv(EnvVaultSecretID, "vlock-secret")
+
+	configPath := filepath.Join(t.TempDir(), "test.cfg")
+	configContent := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_default_sharedSecret=vault://secret/data/voltage#dek_shared_secret
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// Repeated loads, as by a Watcher, reuse the token and the cached secret
+	for i := 0; i < 3; i++ {
+		if _, err := LoadConfig(configPath); err != nil {
+			t.Fatalf("LoadConfig failed: %v", err)
+		}
+	}
+	if n := fv.count(http.MethodPost, "/v1/auth/approle/login"); n != 1 {
+		t.Errorf("Expected one AppRole login across loads, got %d", n)
+	}
+	if n := fv.count(http.MethodGet, "/v1/secret/data/voltage"); n != 1 {
+		t.Errorf("Expected one KV read across loads, got %d", n)
+	}
+
+	// New credentials in the environment take effect
+	fv.mu.Lock()
+	fv.secretID = "rotated-secret"
+	fv.mu.Unlock()
+	fv.revokeAll()
+	t.Setenv(EnvVaultSecretID, "rotated-secret")
+	if _, err := LoadConfig(configPath); err != nil {
+		t.Fatalf("LoadConfig after rotating the secret ID failed: %v", err)
+	}
+	if n := fv.count(http.MethodPost, "/v1/auth/approle/login"); n != 2 {
+		t.Errorf("Expected a login with the new secret ID, got %d logins", n)
+	}
+}
+
+func TestWatcherReloadSkipsVaultCache(t *testing.T) {
+	fv, server := newFakeVault(t)
+	t.Setenv(EnvVaultAddr, server.URL)
+	t.Setenv(EnvVaultToken, "root-token")
+
+	configPath := filepath.Join(t.TempDir(), "test.cfg")
+	writeConfig := func(timeout string) {
+		content := `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_networkTimeout=` + timeout + `
+fp_default_sharedSecret=vault://secret/data/voltage#dek_shared_secret
+`
+		if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+	writeConfig("10")
+
+	watcher, err := NewWatcher(configPath, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+
+	// Rotate the credential in Vault; plain loads keep the cached value for the TTL
+	fv.mu.Lock()
+	fv.secrets["secret/data/voltage"]["dek_shared_secret"] = "rotated-dek"
+	fv.mu.Unlock()
+	if cfg, err := LoadConfig(configPath); err != nil || cfg.DEKSharedSecret != "vault-dek" {
+		t.Fatalf("Expected the cached secret from LoadConfig, got %v", err)
+	}
+
+	writeConfig("20")
+	if changed, err := watcher.Check(); !changed || err != nil {
+		t.Fatalf("Check after edit = %v, %v", changed, err)
+	}
+	if got := watcher.Config().DEKSharedSecret; got != "rotated-dek" {
+		t.Errorf("Expected the reload to read the rotated secret, got %q", got)
+	}
+
+	// Vault is not watched, so a rotation with unchanged files needs Reload
+	fv.mu.Lock()
+	fv.secrets["secret/data/voltage"]["dek_shared_secret"] = "rotated-again"
+	fv.mu.Unlock()
+	if changed, err := watcher.Check(); changed || err != nil {
+		t.Fatalf("Check without file changes = %v, %v", changed, err)
+	}
+	var delivered []*Config
+	watcher.OnChange(func(cfg *Config) { delivered = append(delivered, cfg) })
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if len(delivered) != 1 || delivered[0].DEKSharedSecret != "rotated-again" {
+		t.Errorf("Expected Reload to deliver the rotated secret, got %+v", delivered)
+	}
+}
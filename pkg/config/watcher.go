@@ -0,0 +1,193 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watcher.Run polls when no interval is given
+const DefaultWatchInterval = 5 * time.Second
+
+// Watcher reloads a configuration when its files change
+// It polls the .cfg file, the vsconfig.xml it references and any file:// secrets,
+// so it also notices files replaced by symlink swaps, as in Kubernetes volume mounts.
+// A changed configuration is loaded and validated like LoadConfig before it is
+// passed to the OnChange callbacks; a configuration that fails goes to OnError instead
+//
+// vault://, env:// and other secret references are not watched: a secret rotated in
+// Vault or a changed environment variable only takes effect when a file change triggers a
+// reload. Call Reload after rotating them, for example from a rotation hook or on a timer;
+// reloads read every secret afresh, bypassing provider caches
+//
+//	watcher.OnChange(func(cfg *config.Config) {
+//		if err := client.ApplyConfig(cfg); err != nil {
+//			log.Printf("configuration not applied: %v", err)
+//		}
+//	})
+//	go watcher.Run(ctx)
+type Watcher struct {
+	path     string
+	interval time.Duration
+	options  []LoadOption
+
+	mu          sync.Mutex
+	current     *Config
+	files       []string // Files the current configuration was read from
+	fingerprint string
+	onChange    []func(*Config)
+	onError     []func(error)
+}
+
+// NewWatcher loads the configuration at configPath and returns a watcher for it
+// opts are used for every load; interval is the Run polling period, DefaultWatchInterval if zero
+func NewWatcher(configPath string, interval time.Duration, opts ...LoadOption) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	w := &Watcher{path: configPath, interval: interval, options: opts}
+
+	cfg, files, err := w.load()
+	if err != nil {
+		return nil, err
+	}
+	w.current = cfg
+	w.files = files
+	w.fingerprint = fingerprintFiles(files)
+	return w, nil
+}
+
+// Config returns the last configuration loaded successfully
+func (w *Watcher) Config() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// OnChange registers fn to receive each newly loaded configuration
+func (w *Watcher) OnChange(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = append(w.onChange, fn)
+}
+
+// OnError registers fn to receive errors of configurations that failed to load
+// Each change is reported once; the previous configuration stays current
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onError = append(w.onError, fn)
+}
+
+// Run polls for changes until ctx is done and returns ctx.Err()
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check reloads the configuration if any of its files changed since the last check
+// It reports whether a new configuration was loaded, after notifying the callbacks
+func (w *Watcher) Check() (bool, error) {
+	return w.check(false)
+}
+
+// Reload loads the configuration even if none of its files changed, picking up secrets
+// the Watcher does not watch, and notifies the callbacks like Check
+func (w *Watcher) Reload() error {
+	_, err := w.check(true)
+	return err
+}
+
+// check is Check that reloads unchanged files too if force is set
+func (w *Watcher) check(force bool) (bool, error) {
+	w.mu.Lock()
+	files := w.files
+	before := fingerprintFiles(files)
+	if before == w.fingerprint && !force {
+		w.mu.Unlock()
+		return false, nil
+	}
+
+	cfg, newFiles, err := w.load()
+	if err != nil {
+		// Remember the broken state so it is reported once, not on every poll
+		w.fingerprint = before
+		callbacks := slices.Clone(w.onError)
+		w.mu.Unlock()
+		for _, fn := range callbacks {
+			fn(err)
+		}
+		return false, err
+	}
+
+	w.current = cfg
+	w.files = newFiles
+	w.fingerprint = before
+	if !slices.Equal(files, newFiles) {
+		w.fingerprint = fingerprintFiles(newFiles)
+	}
+	callbacks := slices.Clone(w.onChange)
+	w.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(cfg)
+	}
+	return true, nil
+}
+
+// load runs LoadConfig, recording the files the configuration was read from
+// Secrets are read afresh, bypassing provider caches, so a reload sees rotated credentials
+func (w *Watcher) load() (*Config, []string, error) {
+	options := newLoadOptions(w.options)
+
+	var secretFiles []string
+	resolver := options.resolver().clone()
+	if file, ok := resolver.providers["file"]; ok {
+		resolver.providers["file"] = SecretProviderFunc(func(ctx context.Context, ref string) (string, error) {
+			secretFiles = append(secretFiles, ref)
+			return file.Secret(ctx, ref)
+		})
+	}
+
+	options.secrets, options.providers = resolver, nil
+	options.freshSecrets = true
+	cfg, err := loadConfig(w.path, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to reload %s: %w", w.path, err)
+	}
+
+	files := []string{w.path}
+	if cfg.XMLConfigPath != "" {
+		files = append(files, cfg.XMLConfigPath)
+	}
+	return cfg, append(files, secretFiles...), nil
+}
+
+// fingerprintFiles hashes the contents of files; missing files hash differently from empty ones
+func fingerprintFiles(files []string) string {
+	h := sha256.New()
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(h, "%s\x00missing\x00", path)
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watcherTestConfig = `fp_appName=TestApp
+fp_appVersion=1.0.0
+fp_appEnv=DEV
+fp_networkTimeout=%TIMEOUT%
+fp_default_sharedSecret=file://%SECRET%
+`
+
+func writeWatcherConfig(t *testing.T, path, timeout, secretPath string) {
+	t.Helper()
+	content := strings.NewReplacer("%TIMEOUT%", timeout, "%SECRET%", secretPath).Replace(watcherTestConfig)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestWatcherReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "test.cfg")
+	secretPath := filepath.Join(dir, "dek")
+	if err := os.WriteFile(secretPath, []byte("first-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	writeWatcherConfig(t, configPath, "10", secretPath)
+
+	watcher, err := NewWatcher(configPath, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	var changes []*Config
+	var failures []error
+	watcher.OnChange(func(cfg *Config) { changes = append(changes, cfg) })
+	watcher.OnError(func(err error) { failures = append(failures, err) })
+
+	if changed, err := watcher.Check(); changed || err != nil {
+		t.Fatalf("Check without changes = %v, %v", changed, err)
+	}
+
+	writeWatcherConfig(t, configPath, "25", secretPath)
+	if changed, err := watcher.Check(); !changed || err != nil {
+		t.Fatalf("Check after edit = %v, %v", changed, err)
+	}
+	if len(changes) != 1 || changes[0].NetworkTimeout != 25 || watcher.Config().NetworkTimeout != 25 {
+		t.Errorf("New configuration not delivered: %+v", changes)
+	}
+
+	// A rotated secret file is a change too
+	if err := os.WriteFile(secretPath, []byte("rotated-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	if changed, err := watcher.Check(); !changed || err != nil {
+		t.Fatalf("Check after secret rotation = %v, %v", changed, err)
+	}
+	if watcher.Config().DEKSharedSecret != "rotated-secret" {
+		t.Errorf("Rotated secret not loaded: %q", watcher.Config().DEKSharedSecret)
+	}
+
+	// An invalid configuration is reported once and the last good one kept
+	if err := os.WriteFile(configPath, []byte("fp_appName=TestApp\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if changed, err := watcher.Check(); changed || err == nil {
+		t.Fatalf("Check of an invalid configuration = %v, %v", changed, err)
+	}
+	if changed, err := watcher.Check(); changed || err != nil {
+		t.Errorf("An unchanged invalid configuration should not be reported again: %v, %v", changed, err)
+	}
+	var cfgErr *ConfigError
+	if len(failures) != 1 || !errors.As(failures[0], &cfgErr) {
+		t.Errorf("Expected one ConfigError, got %v", failures)
+	}
+	if watcher.Config().NetworkTimeout != 25 || len(changes) != 2 {
+		t.Error("The last good configuration should stay current")
+	}
+
+	writeWatcherConfig(t, configPath, "30", secretPath)
+	if changed, err := watcher.Check(); !changed || err != nil {
+		t.Fatalf("Check after fix = %v, %v", changed, err)
+	}
+	if len(changes) != 3 || changes[2].NetworkTimeout != 30 {
+		t.Errorf("Fixed configuration not delivered")
+	}
+}
+
+func TestWatcherWatchesXML(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "test.cfg")
+	xmlPath := filepath.Join(dir, "vsconfig.xml")
+	xml := `<VoltageSecurityConfiguration><cryptId name="SSN" algorithm="FPE" key="k" format="NUMERIC"/></VoltageSecurityConfiguration>`
+	if err := os.WriteFile(xmlPath, []byte(xml), 0600); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+	content := "fp_appName=TestApp\nfp_appVersion=1.0.0\nfp_appEnv=DEV\nfp_default_sharedSecret=s\nXMLConfig=" + xmlPath + "\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	watcher, err := NewWatcher(configPath, 0)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	if err := os.WriteFile(xmlPath, []byte(strings.Replace(xml, `key="k"`, `key="k2"`, 1)), 0600); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+	if changed, err := watcher.Check(); !changed || err != nil {
+		t.Errorf("Check after XML edit = %v, %v", changed, err)
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "test.cfg")
+	secretPath := filepath.Join(dir, "dek")
+	if err := os.WriteFile(secretPath, []byte("secret"), 0600); err != nil {
+		t.Fatalf("Failed to write secret: %v", err)
+	}
+	writeWatcherConfig(t, configPath, "10", secretPath)
+
+	watcher, err := NewWatcher(configPath, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	changed := make(chan *Config, 1)
+	watcher.OnChange(func(cfg *Config) { changed <- cfg })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- watcher.Run(ctx) }()
+
+	writeWatcherConfig(t, configPath, "15", secretPath)
+	select {
+	case cfg := <-changed:
+		if cfg.NetworkTimeout != 15 {
+			t.Errorf("Unexpected configuration: %d", cfg.NetworkTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not pick up the change")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run returned %v", err)
+	}
+}
+
+func TestNewWatcherRejectsInvalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "test.cfg")
+	if err := os.WriteFile(configPath, []byte("fp_appName=TestApp\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := NewWatcher(configPath, 0); err == nil {
+		t.Error("Expected NewWatcher to reject an invalid configuration")
+	}
+}
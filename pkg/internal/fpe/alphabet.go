@@ -0,0 +1,56 @@
+package fpe
+
+import (
+	"fmt"
+)
+
+// Common alphabets for format-preserving encryption
+const (
+	Numeric      = "0123456789"
+	Alphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+// Alphabet maps characters to numerals and back
+type Alphabet struct {
+	chars []rune
+	index map[rune]uint16
+}
+
+// NewAlphabet creates an alphabet from a string of distinct characters
+func NewAlphabet(chars string) (*Alphabet, error) {
+	runes := []rune(chars)
+	if err := checkRadix(len(runes)); err != nil {
+		return nil, err
+	}
+
+	index := make(map[rune]uint16, len(runes))
+	for i, r := range runes {
+		if _, dup := index[r]; dup {
+			return nil, fmt.Errorf("fpe: duplicate character %q in alphabet", r)
+		}
+		index[r] = uint16(i)
+	}
+
+	return &Alphabet{chars: runes, index: index}, nil
+}
+
+// Radix returns the number of characters in the alphabet
+func (a *Alphabet) Radix() int {
+	return len(a.chars)
+}
+
+// Contains reports whether r belongs to the alphabet
+func (a *Alphabet) Contains(r rune) bool {
+	_, ok := a.index[r]
+	return ok
+}
+
+// Numeral returns the numeral for r, which must belong to the alphabet
+func (a *Alphabet) Numeral(r rune) uint16 {
+	return a.index[r]
+}
+
+// Char returns the character for numeral d
+func (a *Alphabet) Char(d uint16) rune {
+	return a.chars[d]
+}
@@ -0,0 +1,154 @@
+package fpe
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math/big"
+)
+
+// ff1Rounds is the number of Feistel rounds in FF1
+const ff1Rounds = 10
+
+// FF1 implements the FF1 mode of SP 800-38G
+type FF1 struct {
+	block  cipher.Block
+	tweak  []byte
+	radix  int
+	minLen int
+}
+
+// NewFF1 creates an FF1 cipher with the given AES key, tweak and radix
+// The tweak may be empty
+func NewFF1(key, tweak []byte, radix int) (*FF1, error) {
+	if err := checkRadix(radix); err != nil {
+		return nil, err
+	}
+	block, err := newBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	return &FF1{
+		block:  block,
+		tweak:  append([]byte(nil), tweak...),
+		radix:  radix,
+		minLen: minLength(radix),
+	}, nil
+}
+
+// Radix returns the base of the numeral strings handled by the cipher
+func (f *FF1) Radix() int { return f.radix }
+
+// MinLength returns the shortest numeral string the cipher accepts
+func (f *FF1) MinLength() int { return f.minLen }
+
+// Encrypt returns the FF1 encryption of x
+func (f *FF1) Encrypt(x []uint16) ([]uint16, error) {
+	return f.crypt(x, true)
+}
+
+// Decrypt returns the FF1 decryption of x
+func (f *FF1) Decrypt(x []uint16) ([]uint16, error) {
+	return f.crypt(x, false)
+}
+
+func (f *FF1) crypt(x []uint16, encrypt bool) ([]uint16, error) {
+	n := len(x)
+	if n < f.minLen || uint64(n) > 1<<32-1 {
+		return nil, ErrInvalidLength
+	}
+	if err := checkNumerals(x, f.radix); err != nil {
+		return nil, err
+	}
+
+	u := n / 2
+	v := n - u
+	a := append([]uint16(nil), x[:u]...)
+	b := append([]uint16(nil), x[u:]...)
+
+	// b bytes are enough to hold any v-numeral value; d bytes of PRF output are consumed per round
+	byteLen := (new(big.Int).Sub(pow(f.radix, v), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((byteLen+3)/4) + 4
+
+	t := len(f.tweak)
+	p := make([]byte, 16)
+	p[0], p[1], p[2] = 1, 2, 1
+	p[3] = byte(f.radix >> 16)
+	p[4] = byte(f.radix >> 8)
+	p[5] = byte(f.radix)
+	p[6] = 10
+	p[7] = byte(u)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(t))
+
+	pad := (16 - (t+byteLen+1)%16) % 16
+	q := make([]byte, t+pad+1+byteLen)
+	copy(q, f.tweak)
+
+	modU := pow(f.radix, u)
+	modV := pow(f.radix, v)
+
+	for round := 0; round < ff1Rounds; round++ {
+		i := round
+		if !encrypt {
+			i = ff1Rounds - 1 - round
+		}
+
+		// The round function always consumes the half that is carried over unchanged
+		src := b
+		if !encrypt {
+			src = a
+		}
+		q[t+pad] = byte(i)
+		putUint(q[t+pad+1:], num(src, f.radix))
+
+		y := new(big.Int).SetBytes(f.expand(f.prf(p, q), d))
+
+		m, mod := u, modU
+		if i%2 == 1 {
+			m, mod = v, modV
+		}
+
+		if encrypt {
+			c := new(big.Int).Add(num(a, f.radix), y)
+			c.Mod(c, mod)
+			a, b = b, str(c, f.radix, m)
+		} else {
+			c := new(big.Int).Sub(num(b, f.radix), y)
+			c.Mod(c, mod)
+			b, a = a, str(c, f.radix, m)
+		}
+	}
+
+	return append(a, b...), nil
+}
+
+// prf computes the CBC-MAC of p || q with a zero IV (PRF in the spec)
+func (f *FF1) prf(p, q []byte) []byte {
+	y := make([]byte, 16)
+	f.block.Encrypt(y, p)
+	for off := 0; off < len(q); off += 16 {
+		for j := 0; j < 16; j++ {
+			y[j] ^= q[off+j]
+		}
+		f.block.Encrypt(y, y)
+	}
+	return y
+}
+
+// expand stretches the PRF output r to d bytes (step 6.iii in the spec)
+func (f *FF1) expand(r []byte, d int) []byte {
+	s := make([]byte, 0, ((d+15)/16)*16)
+	s = append(s, r...)
+	block := make([]byte, 16)
+	for j := 1; len(s) < d; j++ {
+		copy(block, r)
+		var ctr [16]byte
+		binary.BigEndian.PutUint64(ctr[8:], uint64(j))
+		for k := range block {
+			block[k] ^= ctr[k]
+		}
+		f.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+	return s[:d]
+}
@@ -0,0 +1,131 @@
+// Package fpe implements the NIST SP 800-38G format-preserving encryption modes
+// FF1 and FF3-1 on top of AES
+//
+// Plaintexts and ciphertexts are numeral strings: slices of integers in [0, radix)
+// Mapping characters to numerals is the caller's job (see Alphabet)
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// minDomainSize is the minimum number of possible inputs (radix^len) required by SP 800-38G Rev 1
+const minDomainSize = 1000000
+
+// Errors returned by the FF1 and FF3-1 ciphers
+var (
+	ErrInvalidRadix   = errors.New("fpe: radix must be between 2 and 65536")
+	ErrInvalidLength  = errors.New("fpe: input length outside the allowed range for this radix")
+	ErrInvalidNumeral = errors.New("fpe: numeral out of range for radix")
+	ErrInvalidTweak   = errors.New("fpe: invalid tweak length")
+)
+
+// Cipher is a format-preserving cipher over numeral strings of a fixed radix
+type Cipher interface {
+	// Encrypt returns the encryption of the numeral string x
+	Encrypt(x []uint16) ([]uint16, error)
+	// Decrypt returns the decryption of the numeral string x
+	Decrypt(x []uint16) ([]uint16, error)
+	// Radix returns the base of the numeral strings handled by the cipher
+	Radix() int
+	// MinLength returns the shortest numeral string the cipher accepts
+	MinLength() int
+}
+
+// newBlock creates the AES block cipher used by both modes
+func newBlock(key []byte) (cipher.Block, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("fpe: invalid AES key length %d", len(key))
+	}
+	return aes.NewCipher(key)
+}
+
+// minLength returns the smallest length for which radix^len >= minDomainSize
+func minLength(radix int) int {
+	length := 1
+	for domain := radix; domain < minDomainSize; domain *= radix {
+		length++
+	}
+	return length
+}
+
+// checkRadix validates radix for both modes
+func checkRadix(radix int) error {
+	if radix < 2 || radix > 1<<16 {
+		return ErrInvalidRadix
+	}
+	return nil
+}
+
+// num interprets x as a big-endian number in the given radix (NUM_radix in the spec)
+func num(x []uint16, radix int) *big.Int {
+	r := big.NewInt(int64(radix))
+	n := new(big.Int)
+	for _, d := range x {
+		n.Mul(n, r)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return n
+}
+
+// str writes n as a numeral string of exactly length m in the given radix (STR^m_radix in the spec)
+// n must be in [0, radix^m)
+func str(n *big.Int, radix, m int) []uint16 {
+	out := make([]uint16, m)
+	r := big.NewInt(int64(radix))
+	v := new(big.Int).Set(n)
+	d := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		v.DivMod(v, r, d)
+		out[i] = uint16(d.Int64())
+	}
+	return out
+}
+
+// pow returns radix^m
+func pow(radix, m int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(radix)), big.NewInt(int64(m)), nil)
+}
+
+// checkNumerals ensures every numeral of x is valid for radix
+func checkNumerals(x []uint16, radix int) error {
+	for _, d := range x {
+		if int(d) >= radix {
+			return ErrInvalidNumeral
+		}
+	}
+	return nil
+}
+
+// rev returns x reversed
+func rev(x []uint16) []uint16 {
+	out := make([]uint16, len(x))
+	for i, d := range x {
+		out[len(x)-1-i] = d
+	}
+	return out
+}
+
+// revBytes returns b reversed
+func revBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i, v := range b {
+		out[len(b)-1-i] = v
+	}
+	return out
+}
+
+// putUint writes v into b as a fixed-width big-endian integer, left-padding with zeros
+// b must be large enough to hold v
+func putUint(b []byte, v *big.Int) {
+	for i := range b {
+		b[i] = 0
+	}
+	v.FillBytes(b)
+}
@@ -0,0 +1,162 @@
+package fpe
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("Invalid hex %q: %v", s, err)
+	}
+	return b
+}
+
+func toNumerals(t *testing.T, alphabet *Alphabet, s string) []uint16 {
+	t.Helper()
+	out := make([]uint16, 0, len(s))
+	for _, r := range s {
+		if !alphabet.Contains(r) {
+			t.Fatalf("Character %q not in alphabet", r)
+		}
+		out = append(out, alphabet.Numeral(r))
+	}
+	return out
+}
+
+func fromNumerals(alphabet *Alphabet, x []uint16) string {
+	out := make([]rune, len(x))
+	for i, d := range x {
+		out[i] = alphabet.Char(d)
+	}
+	return string(out)
+}
+
+// NIST SP 800-38G FF1 samples
+func TestFF1Vectors(t *testing.T) {
+	base36, _ := NewAlphabet("0123456789abcdefghijklmnopqrstuvwxyz")
+	numeric, _ := NewAlphabet(Numeric)
+
+	tests := []struct {
+		name       string
+		key        string
+		tweak      string
+		alphabet   *Alphabet
+		plaintext  string
+		ciphertext string
+	}{
+		{"Sample 1", "2B7E151628AED2A6ABF7158809CF4F3C", "", numeric, "0123456789", "2433477484"},
+		{"Sample 2", "2B7E151628AED2A6ABF7158809CF4F3C", "39383736353433323130", numeric, "0123456789", "6124200773"},
+		{"Sample 3", "2B7E151628AED2A6ABF7158809CF4F3C", "3737373770717273373737", base36, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"Sample 4", "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F", "", numeric, "0123456789", "2830668132"},
+		{"Sample 7", "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", "", numeric, "0123456789", "6657667009"},
+		{"Sample 9", "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94", "3737373770717273373737", base36, "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewFF1(mustHex(t, tt.key), mustHex(t, tt.tweak), tt.alphabet.Radix())
+			if err != nil {
+				t.Fatalf("NewFF1 failed: %v", err)
+			}
+
+			ct, err := c.Encrypt(toNumerals(t, tt.alphabet, tt.plaintext))
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			if got := fromNumerals(tt.alphabet, ct); got != tt.ciphertext {
+				t.Errorf("Expected ciphertext %s, got %s", tt.ciphertext, got)
+			}
+
+			pt, err := c.Decrypt(ct)
+			if err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			if got := fromNumerals(tt.alphabet, pt); got != tt.plaintext {
+				t.Errorf("Expected plaintext %s, got %s", tt.plaintext, got)
+			}
+		})
+	}
+}
+
+func TestFF31Vectors(t *testing.T) {
+	numeric, _ := NewAlphabet(Numeric)
+
+	c, err := NewFF31(mustHex(t, "EF4359D8D580AA4F7F036D6F04FC6A94"), mustHex(t, "D8E7920AFA330A"), 10)
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+
+	ct, err := c.Encrypt(toNumerals(t, numeric, "890121234567890000"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if got := fromNumerals(numeric, ct); got != "477064185124354662" {
+		t.Errorf("Expected ciphertext 477064185124354662, got %s", got)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	key := mustHex(t, "2B7E151628AED2A6ABF7158809CF4F3CEF4359D8D580AA4F7F036D6F04FC6A94")
+	alphabet, _ := NewAlphabet(Alphanumeric)
+
+	ff1, err := NewFF1(key, nil, alphabet.Radix())
+	if err != nil {
+		t.Fatalf("NewFF1 failed: %v", err)
+	}
+	ff31, err := NewFF31(key, make([]byte, FF31TweakSize), alphabet.Radix())
+	if err != nil {
+		t.Fatalf("NewFF31 failed: %v", err)
+	}
+
+	for _, c := range []Cipher{ff1, ff31} {
+		for _, plaintext := range []string{"abcd", "johnsmithexamplecom", "A1b2C3d4E5f6G7h8"} {
+			x := toNumerals(t, alphabet, plaintext)
+			ct, err := c.Encrypt(x)
+			if err != nil {
+				t.Fatalf("Encrypt(%q) failed: %v", plaintext, err)
+			}
+			if len(ct) != len(x) {
+				t.Errorf("Ciphertext length %d, expected %d", len(ct), len(x))
+			}
+			pt, err := c.Decrypt(ct)
+			if err != nil {
+				t.Fatalf("Decrypt failed: %v", err)
+			}
+			if got := fromNumerals(alphabet, pt); got != plaintext {
+				t.Errorf("Round trip of %q returned %q", plaintext, got)
+			}
+		}
+	}
+}
+
+func TestInvalidInputs(t *testing.T) {
+	key := mustHex(t, "2B7E151628AED2A6ABF7158809CF4F3C")
+
+	if _, err := NewFF1(key[:10], nil, 10); err == nil {
+		t.Error("Expected error for short key")
+	}
+	if _, err := NewFF1(key, nil, 1); err != ErrInvalidRadix {
+		t.Errorf("Expected ErrInvalidRadix, got %v", err)
+	}
+	if _, err := NewFF31(key, []byte{1, 2, 3}, 10); err != ErrInvalidTweak {
+		t.Errorf("Expected ErrInvalidTweak, got %v", err)
+	}
+
+	c, _ := NewFF1(key, nil, 10)
+	if c.MinLength() != 6 {
+		t.Errorf("Expected minimum length 6 for radix 10, got %d", c.MinLength())
+	}
+	if _, err := c.Encrypt([]uint16{1, 2, 3, 4, 5}); err != ErrInvalidLength {
+		t.Errorf("Expected ErrInvalidLength, got %v", err)
+	}
+	if _, err := c.Encrypt([]uint16{1, 2, 3, 4, 5, 10}); err != ErrInvalidNumeral {
+		t.Errorf("Expected ErrInvalidNumeral, got %v", err)
+	}
+
+	if _, err := NewAlphabet("0120"); err == nil {
+		t.Error("Expected error for duplicate alphabet characters")
+	}
+}
@@ -0,0 +1,69 @@
+// Package otelvlock adapts an OpenTelemetry tracer to vlock.Tracer
+// It is a module of its own so that only applications using it depend on OpenTelemetry
+//
+//	client, err := vlock.NewClient(cfg, vlock.WithTracer(otelvlock.NewTracer(otel.Tracer("vlock"))))
+//
+// Protect, access and health check calls then appear as client spans under the
+// span in the caller's context
+package otelvlock
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/daveaugustus/vlock/pkg/vlock"
+)
+
+// Tracer is a vlock.Tracer backed by an OpenTelemetry tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer wraps tracer for use with vlock.WithTracer
+func NewTracer(tracer trace.Tracer) *Tracer {
+	return &Tracer{tracer: tracer}
+}
+
+// Start begins a client span under the span in ctx
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...vlock.SpanAttribute) (context.Context, vlock.Span) {
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(convert(attrs)...))
+	return ctx, &otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+// End records the final attributes and the error, if any, and ends the span
+func (s *otelSpan) End(err error, attrs ...vlock.SpanAttribute) {
+	s.span.SetAttributes(convert(attrs)...)
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
+
+// convert maps vlock attributes to OpenTelemetry ones
+func convert(attrs []vlock.SpanAttribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(attr.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(attr.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(attr.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(attr.Key, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,103 @@
+package otelvlock
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+	"github.com/daveaugustus/vlock/pkg/vlock"
+)
+
+func newTracedClient(t *testing.T) (*vlock.Client, *tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	cfg := &config.Config{
+		AppName:         "TestApp",
+		AppVersion:      "1.0.0",
+		AppEnv:          "DEV",
+		DEKSharedSecret: "test_secret",
+		ConfigFilePath:  "test.cfg",
+		XMLConfigPath:   "../config/dev/vsconfig.xml",
+		DefaultCryptID:  "SSN_Internal",
+	}
+	client, err := vlock.NewClient(cfg,
+		vlock.WithBackend(vlock.NewMockBackend()),
+		vlock.WithTracer(NewTracer(provider.Tracer("test"))))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, recorder, provider
+}
+
+func attrs(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestSpansNestUnderCaller(t *testing.T) {
+	client, recorder, provider := newTracedClient(t)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.AccessText(ctx, "Made_Up_ID", protected); err == nil {
+		t.Fatal("Expected AccessText with an unknown cryptID to fail")
+	}
+	if err := client.HealthCheckContext(ctx); err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 4 {
+		t.Fatalf("Expected 4 spans, got %d", len(spans))
+	}
+	wantNames := []string{"vlock.protect_text", "vlock.access_text", "vlock.health_check", "request"}
+	for i, span := range spans {
+		if span.Name() != wantNames[i] {
+			t.Errorf("Span %d is %s, want %s", i, span.Name(), wantNames[i])
+		}
+		if i < 3 && span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("Span %s is not a child of the caller's span", span.Name())
+		}
+	}
+
+	protect := attrs(spans[0])
+	if protect[vlock.AttrCryptID].AsString() != "SSN_Internal" ||
+		protect[vlock.AttrOperation].AsString() != vlock.AuditProtectText ||
+		protect[vlock.AttrBackend].AsString() != vlock.MockVersion ||
+		protect[vlock.AttrRetries].AsInt64() != 0 {
+		t.Errorf("Unexpected protect span attributes: %v", spans[0].Attributes())
+	}
+	if spans[0].Status().Code == codes.Error {
+		t.Error("Successful operation recorded as an error")
+	}
+
+	access := attrs(spans[1])
+	if access[vlock.AttrErrorCode].AsInt64() != int64(vlock.ErrCryptIDNotFound) || spans[1].Status().Code != codes.Error {
+		t.Errorf("Failed access not recorded: %v %v", spans[1].Attributes(), spans[1].Status())
+	}
+	for _, span := range spans {
+		for _, kv := range span.Attributes() {
+			if kv.Value.Emit() == "123-45-6789" || kv.Value.Emit() == protected {
+				t.Errorf("Span %s leaked data in %s", span.Name(), kv.Key)
+			}
+		}
+	}
+}
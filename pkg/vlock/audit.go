@@ -0,0 +1,334 @@
+package vlock
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// Audited operation names, as recorded in AuditRecord.Operation
+const (
+	AuditProtectText   = "protect_text"
+	AuditProtectBytes  = "protect_bytes"
+	AuditProtectBatch  = "protect_batch"
+	AuditProtectStream = "protect_stream"
+	AuditAccessText    = "access_text"
+	AuditAccessBytes   = "access_bytes"
+	AuditAccessBatch   = "access_batch"
+	AuditAccessStream  = "access_stream"
+	AuditAccessMasked  = "access_masked"
+	AuditReprotect     = "reprotect"
+	AuditInitialize    = "initialize"
+	AuditReinitialize  = "reinitialize"
+	AuditClose         = "close"
+	AuditApplyConfig   = "apply_config"
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditRecord is one entry of the audit trail
+// It never carries plaintext or ciphertext; Seq, PrevHash and Hash are filled in by the sink
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	AppName    string    `json:"app"`
+	Env        string    `json:"env"`
+	Principal  string    `json:"principal,omitempty"`
+	Operation  string    `json:"operation"`
+	CryptID    string    `json:"cryptId,omitempty"`
+	KeyVersion string    `json:"keyVersion,omitempty"`
+	Count      int       `json:"count,omitempty"`    // Items processed; batches and streams count as one request
+	Failures   int       `json:"failures,omitempty"` // Failed batch items
+	Outcome    string    `json:"outcome"`
+	ErrorCode  int       `json:"errorCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Seq        uint64    `json:"seq"`
+	PrevHash   string    `json:"prevHash"`
+	Hash       string    `json:"hash,omitempty"`
+}
+
+// AuditSink receives the records selected by the <audit> policy of vsconfig.xml
+// WriteAudit is called synchronously by the operation being audited and may be called concurrently
+type AuditSink interface {
+	WriteAudit(record AuditRecord) error
+}
+
+// WithAuditSink makes the client write an audit record for every operation its <audit> policy selects
+//
+// logAllOperations selects every operation including initialize, reinitialize, close and apply_config;
+// logEncryption selects protect operations, logDecryption access operations and
+// logKeyAccess Reprotect. Failed lifecycle operations are always recorded. Without a
+// vsconfig.xml every operation is recorded; while one cannot be read, the policy last
+// read applies. A failing sink is logged and does not fail the operation
+func WithAuditSink(sink AuditSink) ClientOption {
+	return func(c *Client) error {
+		if sink == nil {
+			return fmt.Errorf("audit sink cannot be nil")
+		}
+		c.auditSink = sink
+		return nil
+	}
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context whose operations are audited as performed by principal
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set with WithPrincipal, or "" if there is none
+func PrincipalFromContext(ctx context.Context) string {
+	principal, _ := ctx.Value(principalKey{}).(string)
+	return principal
+}
+
+// operationEvent describes a finished public operation, for metrics and the audit trail
+type operationEvent struct {
+	start    time.Time
+	op       string
+	cryptID  string
+	count    int
+	failures int
+	err      error
+}
+
+// batchEvent describes a batch call, counting failed items
+func batchEvent(start time.Time, op, cryptID string, count int, results []BatchResult, err error) operationEvent {
+	event := operationEvent{start: start, op: op, cryptID: cryptID, count: count, err: err}
+	for _, result := range results {
+		if result.Err != nil {
+			event.failures++
+		}
+	}
+	return event
+}
+
+// auditLocked records event if the audit policy selects it; c.mu must be held
+func (c *Client) auditLocked(ctx context.Context, event operationEvent) {
+	if c.auditSink == nil {
+		return
+	}
+	lifecycleFailure := event.err != nil && isLifecycleOperation(event.op)
+	if !lifecycleFailure && !auditSelected(c.auditPolicyLocked(), event.op) {
+		return
+	}
+
+	record := AuditRecord{
+		Time:      time.Now().UTC(),
+		AppName:   c.config.AppName,
+		Env:       c.config.AppEnv,
+		Principal: PrincipalFromContext(ctx),
+		Operation: event.op,
+		CryptID:   event.cryptID,
+		Count:     event.count,
+		Failures:  event.failures,
+		Outcome:   AuditSuccess,
+	}
+	if isProtectOperation(event.op) {
+		record.KeyVersion = c.currentKeyVersion(record.CryptID)
+	}
+	if event.err != nil {
+		record.Outcome = AuditFailure
+		record.Error = event.err.Error()
+		if code, ok := errorCode(event.err); ok {
+			record.ErrorCode = int(code)
+		}
+	}
+
+	if err := c.auditSink.WriteAudit(record); err != nil {
+		c.logger.Error("audit record not written", "operation", event.op, "error", err.Error())
+	}
+}
+
+// auditPolicyLocked returns the <audit> policy in effect: that of the loaded vsconfig.xml,
+// else the last one read, else, for a client without vsconfig.xml, one selecting everything
+func (c *Client) auditPolicyLocked() config.AuditPolicy {
+	switch {
+	case c.security != nil:
+		return c.security.Audit
+	case c.auditPolicy != nil:
+		return *c.auditPolicy
+	default:
+		return config.AuditPolicy{LogAllOperations: true}
+	}
+}
+
+// rememberAuditPolicyLocked keeps the <audit> policy of security for when no vsconfig.xml is loaded
+func (c *Client) rememberAuditPolicyLocked(security *config.SecurityConfig) {
+	if security != nil {
+		policy := security.Audit
+		c.auditPolicy = &policy
+	}
+}
+
+// auditSelected reports whether policy asks for op to be audited
+func auditSelected(policy config.AuditPolicy, op string) bool {
+	switch {
+	case policy.LogAllOperations:
+		return true
+	case isLifecycleOperation(op):
+		return false
+	case op == AuditReprotect:
+		return policy.LogKeyAccess
+	case isProtectOperation(op):
+		return policy.LogEncryption
+	default:
+		return policy.LogDecryption
+	}
+}
+
+func isLifecycleOperation(op string) bool {
+	return op == AuditInitialize || op == AuditReinitialize || op == AuditClose || op == AuditApplyConfig
+}
+
+func isProtectOperation(op string) bool {
+	switch op {
+	case AuditProtectText, AuditProtectBytes, AuditProtectBatch, AuditProtectStream, AuditReprotect:
+		return true
+	}
+	return false
+}
+
+// AuditFile is an AuditSink appending JSON lines to a file
+// Each record carries the hash of the one before it, so edited, removed or
+// reordered lines are detected by VerifyAuditLog
+type AuditFile struct {
+	mu       sync.Mutex
+	file     *os.File
+	seq      uint64
+	lastHash string
+}
+
+// NewAuditFile opens path for appending, creating it with mode 0600 if needed
+// An existing trail is verified and continued from its last record
+func NewAuditFile(path string) (*AuditFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	last, err := verifyAuditLog(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %w", path, err)
+	}
+
+	a := &AuditFile{file: file}
+	if last != nil {
+		a.seq = last.Seq
+		a.lastHash = last.Hash
+	}
+	return a, nil
+}
+
+// WriteAudit chains record to the previous one and appends it to the file
+func (a *AuditFile) WriteAudit(record AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return fmt.Errorf("audit log is closed")
+	}
+
+	record.Seq = a.seq + 1
+	record.PrevHash = a.lastHash
+	hash, err := hashAuditRecord(record)
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	if _, err := a.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+
+	a.seq = record.Seq
+	a.lastHash = record.Hash
+	return nil
+}
+
+// Close closes the file; later writes fail
+func (a *AuditFile) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// VerifyAuditLog checks the hash chain of an audit trail written by AuditFile
+// It returns the number of records checked and an error naming the first broken line
+func VerifyAuditLog(r io.Reader) (int, error) {
+	last, err := verifyAuditLog(r)
+	if last == nil {
+		return 0, err
+	}
+	return int(last.Seq), err
+}
+
+// verifyAuditLog checks the chain and returns the last valid record, nil for an empty trail
+func verifyAuditLog(r io.Reader) (*AuditRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var last *AuditRecord
+	for line := 1; scanner.Scan(); line++ {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return last, fmt.Errorf("line %d: invalid audit record: %w", line, err)
+		}
+
+		wantPrev := ""
+		if last != nil {
+			wantPrev = last.Hash
+		}
+		if record.Seq != uint64(line) || record.PrevHash != wantPrev {
+			return last, fmt.Errorf("line %d: audit chain broken (record removed or reordered)", line)
+		}
+		hash, err := hashAuditRecord(record)
+		if err != nil {
+			return last, err
+		}
+		if hash != record.Hash {
+			return last, fmt.Errorf("line %d: audit record hash mismatch (record modified)", line)
+		}
+
+		last = &record
+	}
+	if err := scanner.Err(); err != nil {
+		return last, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return last, nil
+}
+
+// hashAuditRecord returns the hex SHA-256 of record's JSON without its Hash field
+func hashAuditRecord(record AuditRecord) (string, error) {
+	record.Hash = ""
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,304 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryAuditSink collects audit records in memory
+type memoryAuditSink struct {
+	mu      sync.Mutex
+	records []AuditRecord
+}
+
+func (s *memoryAuditSink) WriteAudit(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *memoryAuditSink) operations() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ops := make([]string, len(s.records))
+	for i, record := range s.records {
+		ops[i] = record.Operation
+	}
+	return ops
+}
+
+// newAuditTestClient starts a mock-backed client whose vsconfig.xml has the given <audit> body
+func newAuditTestClient(t *testing.T, audit string, sink AuditSink) *Client {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vsconfig.xml")
+	xml := fmt.Sprintf(`<VoltageSecurityConfiguration>
+  <cryptId name="SSN_Internal" algorithm="FPE" key="key-ssn" format="NUMERIC">
+    <keyVersion>v2</keyVersion>
+  </cryptId>
+  <cryptId name="BINARY_Internal" algorithm="AES256" key="key-bin" format="BINARY"/>
+  <audit>%s</audit>
+</VoltageSecurityConfiguration>`, audit)
+	if err := os.WriteFile(path, []byte(xml), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = path
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()), WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestAuditPolicySelectsOperations(t *testing.T) {
+	sink := &memoryAuditSink{}
+	client := newAuditTestClient(t, "<logEncryption>true</logEncryption>", sink)
+	ctx := WithPrincipal(context.Background(), "alice@example.com")
+
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.AccessText(ctx, "", protected); err != nil {
+		t.Fatalf("AccessText failed: %v", err)
+	}
+
+	if ops := sink.operations(); len(ops) != 1 || ops[0] != AuditProtectText {
+		t.Fatalf("Expected only protect_text to be audited, got %v", ops)
+	}
+	record := sink.records[0]
+	if record.Principal != "alice@example.com" || record.AppName != "TestApp" || record.Env != "DEV" {
+		t.Errorf("Unexpected record identity: %+v", record)
+	}
+	if record.CryptID != "SSN_Internal" || record.KeyVersion != "v2" || record.Outcome != AuditSuccess {
+		t.Errorf("Unexpected record details: %+v", record)
+	}
+	if strings.Contains(fmt.Sprintf("%+v", record), "6789") {
+		t.Errorf("Audit record leaked data: %+v", record)
+	}
+}
+
+func TestAuditAllOperations(t *testing.T) {
+	sink := &memoryAuditSink{}
+	client := newAuditTestClient(t, "<logAllOperations>true</logAllOperations>", sink)
+	ctx := context.Background()
+
+	if _, err := client.AccessText(ctx, "Unknown_ID", "123"); err == nil {
+		t.Fatal("Expected AccessText with an unknown cryptID to fail")
+	}
+	results, err := client.ProtectBatch(ctx, "", []string{"123-45-6789", ""})
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	if results[1].Err == nil {
+		t.Fatal("Expected the empty batch item to fail")
+	}
+
+	var protected bytes.Buffer
+	payload := bytes.Repeat([]byte("x"), 3*1024)
+	if err := client.ProtectStream(ctx, "BINARY_Internal", &protected, bytes.NewReader(payload)); err != nil {
+		t.Fatalf("ProtectStream failed: %v", err)
+	}
+	if err := client.AccessStream(ctx, "", &bytes.Buffer{}, &protected); err != nil {
+		t.Fatalf("AccessStream failed: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	want := []string{AuditInitialize, AuditAccessText, AuditProtectBatch, AuditProtectStream, AuditAccessStream, AuditClose}
+	if got := sink.operations(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("Audited operations = %v, want %v", got, want)
+	}
+
+	failed := sink.records[1]
+	if failed.Outcome != AuditFailure || failed.ErrorCode != int(ErrCryptIDNotFound) || failed.CryptID != "Unknown_ID" {
+		t.Errorf("Unexpected failure record: %+v", failed)
+	}
+	batch := sink.records[2]
+	if batch.Count != 2 || batch.Failures != 1 || batch.Outcome != AuditSuccess {
+		t.Errorf("Unexpected batch record: %+v", batch)
+	}
+	if stream := sink.records[4]; stream.CryptID != "BINARY_Internal" || stream.Count != 1 {
+		t.Errorf("Unexpected access stream record: %+v", stream)
+	}
+}
+
+func TestAuditDisabledByPolicy(t *testing.T) {
+	sink := &memoryAuditSink{}
+	client := newAuditTestClient(t, "<logDecryption>false</logDecryption>", sink)
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if ops := sink.operations(); len(ops) != 0 {
+		t.Errorf("Expected no audit records, got %v", ops)
+	}
+}
+
+func TestAuditKeyAccess(t *testing.T) {
+	sink := &memoryAuditSink{}
+	client := newAuditTestClient(t, "<logKeyAccess>true</logKeyAccess>", sink)
+	ctx := context.Background()
+
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.Reprotect(ctx, "", protected); err != nil {
+		t.Fatalf("Reprotect failed: %v", err)
+	}
+	if ops := sink.operations(); len(ops) != 1 || ops[0] != AuditReprotect {
+		t.Errorf("Expected only reprotect to be audited, got %v", ops)
+	}
+}
+
+func TestAuditFileChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewAuditFile(path)
+	if err != nil {
+		t.Fatalf("NewAuditFile failed: %v", err)
+	}
+	for _, op := range []string{AuditProtectText, AuditAccessText} {
+		if err := sink.WriteAudit(AuditRecord{Operation: op, Outcome: AuditSuccess}); err != nil {
+			t.Fatalf("WriteAudit failed: %v", err)
+		}
+	}
+	sink.Close()
+
+	// Reopening continues the chain
+	sink, err = NewAuditFile(path)
+	if err != nil {
+		t.Fatalf("Reopening the audit log failed: %v", err)
+	}
+	if err := sink.WriteAudit(AuditRecord{Operation: AuditClose, Outcome: AuditSuccess}); err != nil {
+		t.Fatalf("WriteAudit failed: %v", err)
+	}
+	sink.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat audit log: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected audit log mode 0600, got %v", info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	n, err := VerifyAuditLog(bytes.NewReader(data))
+	if err != nil || n != 3 {
+		t.Fatalf("VerifyAuditLog = %d, %v; want 3 records", n, err)
+	}
+
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+	tampered := []struct {
+		name string
+		log  string
+		line string
+	}{
+		{"modified", strings.Replace(string(data), `"outcome":"success"`, `"outcome":"failure"`, 1), "line 1"},
+		{"removed", lines[0] + lines[2] + "\n", "line 2"},
+		{"reordered", lines[1] + lines[0] + lines[2] + "\n", "line 1"},
+	}
+	for _, tt := range tampered {
+		if _, err := VerifyAuditLog(strings.NewReader(tt.log)); err == nil || !strings.Contains(err.Error(), tt.line) {
+			t.Errorf("%s: expected an error at %s, got %v", tt.name, tt.line, err)
+		}
+	}
+
+	// A tampered trail is not silently extended
+	if err := os.WriteFile(path, []byte(tampered[0].log), 0o600); err != nil {
+		t.Fatalf("Failed to write audit log: %v", err)
+	}
+	if _, err := NewAuditFile(path); err == nil {
+		t.Error("Expected NewAuditFile to reject a tampered audit log")
+	}
+}
+
+// failingAuditSink rejects every record
+type failingAuditSink struct{}
+
+func (failingAuditSink) WriteAudit(AuditRecord) error { return errors.New("disk full") }
+
+func TestAuditSinkFailureDoesNotFailOperation(t *testing.T) {
+	client := newAuditTestClient(t, "<logAllOperations>true</logAllOperations>", failingAuditSink{})
+	logger, buf := newCapturingLogger()
+	client.logger = logger
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "audit record not written") {
+		t.Errorf("Expected the sink failure to be logged, got %q", buf.String())
+	}
+}
+
+func TestAuditWithoutLoadedPolicy(t *testing.T) {
+	// A failed first Initialize is recorded even though the <audit> policy selects nothing
+	path := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, path, "v1")
+	sink := &memoryAuditSink{}
+	backend := &configCheckingBackend{MockBackend: NewMockBackend()}
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = path
+	cfg.DEKSharedSecret = "rejected"
+	client, err := NewClient(cfg, WithBackend(backend), WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err == nil {
+		t.Fatal("Expected Initialize to fail")
+	}
+	if len(sink.records) != 1 || sink.records[0].Operation != AuditInitialize || sink.records[0].Outcome != AuditFailure {
+		t.Fatalf("Expected a failed initialize record, got %+v", sink.records)
+	}
+
+	// A missing vsconfig.xml is recorded too
+	sink = &memoryAuditSink{}
+	cfg = newBackendTestConfig()
+	cfg.XMLConfigPath = filepath.Join(t.TempDir(), "missing.xml")
+	client, err = NewClient(cfg, WithBackend(NewMockBackend()), WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err == nil {
+		t.Fatal("Expected Initialize to fail")
+	}
+	if ops := sink.operations(); len(ops) != 1 || ops[0] != AuditInitialize {
+		t.Fatalf("Expected a failed initialize record, got %v", ops)
+	}
+
+	// Without vsconfig.xml every operation is recorded
+	sink = &memoryAuditSink{}
+	cfg = newBackendTestConfig()
+	cfg.XMLConfigPath = ""
+	client, err = NewClient(cfg, WithBackend(NewMockBackend()), WithAuditSink(sink))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	client.ProtectText(context.Background(), "", "123-45-6789")
+	client.Close()
+	want := []string{AuditInitialize, AuditProtectText, AuditClose}
+	if ops := sink.operations(); strings.Join(ops, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v to be audited, got %v", want, ops)
+	}
+}
@@ -0,0 +1,74 @@
+package vlock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// Backend performs the Voltage operations on behalf of a Client
+// The Client handles argument validation, default cryptIDs and lifecycle state,
+// and delegates the actual work to its Backend
+//
+// Implementations must be safe for concurrent use
+type Backend interface {
+	// Init prepares the backend using the client's configuration
+	Init(cfg *config.Config) error
+	// Terminate releases the resources acquired by Init
+	// Terminating a backend that is not initialized is not an error
+	Terminate() error
+	// HealthCheck verifies the backend can process requests
+	HealthCheck() error
+	// Protect encrypts plaintext with the given cryptID
+	Protect(cryptID, plaintext string) (string, error)
+	// Access decrypts ciphertext with the given cryptID
+	Access(cryptID, ciphertext string) (string, error)
+	// Version describes the backend implementation and version
+	Version() string
+}
+
+// BytesBackend is implemented by backends that can protect raw binary data
+// The client's ProtectBytes and AccessBytes require it
+type BytesBackend interface {
+	// ProtectBytes encrypts data with the given cryptID
+	ProtectBytes(cryptID string, data []byte) ([]byte, error)
+	// AccessBytes decrypts data with the given cryptID
+	AccessBytes(cryptID string, data []byte) ([]byte, error)
+}
+
+// WithBackend makes the client use the given backend instead of the build default
+// (the Voltage C library with CGO, the in-process MockBackend without)
+func WithBackend(backend Backend) ClientOption {
+	return func(c *Client) error {
+		if backend == nil {
+			return fmt.Errorf("backend cannot be nil")
+		}
+		c.backend = backend
+		return nil
+	}
+}
+
+// resolveConfigPath returns the configuration file handed to the Voltage library
+// It prefers the .cfg path and falls back to the XML configuration path
+func resolveConfigPath(cfg *config.Config) (string, error) {
+	if cfg.ConfigFilePath != "" {
+		return cfg.ConfigFilePath, nil
+	}
+	if cfg.XMLConfigPath != "" {
+		return cfg.XMLConfigPath, nil
+	}
+	return "", fmt.Errorf("no configuration file path specified")
+}
+
+// loadSecurityConfig reads vsconfig.xml, reporting failures as Voltage configuration errors
+func loadSecurityConfig(path string) (*config.SecurityConfig, error) {
+	security, err := config.LoadSecurityConfig(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, NewVoltageError(int(ErrConfigNotFound), path)
+	} else if err != nil {
+		return nil, NewVoltageError(int(ErrConfigInvalid), err.Error())
+	}
+	return security, nil
+}
@@ -0,0 +1,197 @@
+package vlock
+
+import (
+	"sync"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// MockVersion is the version reported by MockBackend
+const MockVersion = "1.0.0-mock"
+
+// MockBackend is an in-process Backend that needs no Voltage installation
+// It encrypts with a pure-Go FF1/AES engine keyed from the <cryptId> entries of
+// vsconfig.xml, so values round-trip and keep their format like production
+type MockBackend struct {
+	mu          sync.RWMutex
+	initialized bool
+	configPath  string
+	engine      *softEngine
+}
+
+// NewMockBackend creates an uninitialized mock backend
+func NewMockBackend() *MockBackend {
+	return &MockBackend{}
+}
+
+// Init loads the cryptIds from the configured vsconfig.xml and keys the engine
+func (b *MockBackend) Init(cfg *config.Config) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.initialized {
+		return ErrClientAlreadyInitialized
+	}
+
+	configPath, err := resolveConfigPath(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Build the pure-Go FPE engine from the cryptIds defined in vsconfig.xml
+	var cryptIDs []config.CryptID
+	if cfg.XMLConfigPath != "" {
+		security, err := loadSecurityConfig(cfg.XMLConfigPath)
+		if err != nil {
+			return err
+		}
+		cryptIDs = security.CryptIDs
+	}
+
+	engine, err := newSoftEngine(cryptIDs)
+	if err != nil {
+		return NewVoltageError(int(ErrConfigInvalid), err.Error())
+	}
+
+	b.configPath = configPath
+	b.engine = engine
+	b.initialized = true
+
+	return nil
+}
+
+// Terminate discards the engine
+func (b *MockBackend) Terminate() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.initialized {
+		// Not an error - already terminated or never initialized
+		return nil
+	}
+
+	b.initialized = false
+	b.configPath = ""
+	b.engine = nil
+
+	return nil
+}
+
+// HealthCheck always succeeds once the backend is initialized
+func (b *MockBackend) HealthCheck() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return ErrClientNotInitialized
+	}
+
+	return nil
+}
+
+// Protect encrypts plaintext with the pure-Go engine
+func (b *MockBackend) Protect(cryptID, plaintext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	return b.engine.protect(cryptID, plaintext)
+}
+
+// Access decrypts ciphertext with the pure-Go engine
+func (b *MockBackend) Access(cryptID, ciphertext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	return b.engine.access(cryptID, ciphertext)
+}
+
+// AccessVersion decrypts ciphertext with a current or previous key version of the cryptID
+func (b *MockBackend) AccessVersion(cryptID, keyVersion, ciphertext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	return b.engine.accessVersion(cryptID, keyVersion, ciphertext)
+}
+
+// ProtectBytes encrypts binary data with the pure-Go engine
+func (b *MockBackend) ProtectBytes(cryptID string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return b.engine.protectBytes(cryptID, data)
+}
+
+// AccessBytes decrypts binary data with the pure-Go engine
+func (b *MockBackend) AccessBytes(cryptID string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return b.engine.accessBytes(cryptID, data)
+}
+
+// AccessBytesVersion decrypts binary data with a current or previous key version of the cryptID
+func (b *MockBackend) AccessBytesVersion(cryptID, keyVersion string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return b.engine.accessBytesVersion(cryptID, keyVersion, data)
+}
+
+// ProtectBatch encrypts values with the pure-Go engine under a single lock acquisition
+func (b *MockBackend) ProtectBatch(cryptID string, values []string) ([]BatchResult, error) {
+	return b.batch(values, func(value string) (string, error) {
+		return b.engine.protect(cryptID, value)
+	})
+}
+
+// AccessBatch decrypts values with the pure-Go engine under a single lock acquisition
+func (b *MockBackend) AccessBatch(cryptID string, values []string) ([]BatchResult, error) {
+	return b.batch(values, func(value string) (string, error) {
+		return b.engine.access(cryptID, value)
+	})
+}
+
+// batch applies fn to every value while holding the read lock
+func (b *MockBackend) batch(values []string, fn func(string) (string, error)) ([]BatchResult, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	results := make([]BatchResult, len(values))
+	for i, value := range values {
+		results[i].Value, results[i].Err = fn(value)
+	}
+	return results, nil
+}
+
+// Version returns the mock backend version
+func (b *MockBackend) Version() string {
+	return MockVersion
+}
@@ -0,0 +1,163 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// recordingBackend is a Backend test double that records the calls it receives
+type recordingBackend struct {
+	mu        sync.Mutex
+	calls     []string
+	healthErr error
+}
+
+func (b *recordingBackend) record(call string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.calls = append(b.calls, call)
+}
+
+func (b *recordingBackend) Init(cfg *config.Config) error { b.record("Init"); return nil }
+func (b *recordingBackend) Terminate() error              { b.record("Terminate"); return nil }
+func (b *recordingBackend) HealthCheck() error {
+	b.record("HealthCheck")
+	return b.healthErr
+}
+func (b *recordingBackend) Protect(cryptID, plaintext string) (string, error) {
+	b.record("Protect:" + cryptID)
+	return "p(" + plaintext + ")", nil
+}
+func (b *recordingBackend) Access(cryptID, ciphertext string) (string, error) {
+	b.record("Access:" + cryptID)
+	return ciphertext, nil
+}
+func (b *recordingBackend) Version() string { return "recording" }
+
+func newBackendTestConfig() *config.Config {
+	return &config.Config{
+		AppName:         "TestApp",
+		AppVersion:      "1.0.0",
+		AppEnv:          "DEV",
+		DEKSharedSecret: "test_secret",
+		ConfigFilePath:  "test.cfg",
+		XMLConfigPath:   "../config/dev/vsconfig.xml",
+		DefaultCryptID:  "SSN_Internal",
+	}
+}
+
+func TestWithBackendDelegates(t *testing.T) {
+	backend := &recordingBackend{}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if got, err := client.ProtectText(context.Background(), "", "123456789"); err != nil || got != "p(123456789)" {
+		t.Errorf("Unexpected ProtectText result %q, %v", got, err)
+	}
+	if info := client.Info(); info.BackendVersion != "recording" {
+		t.Errorf("Expected BackendVersion 'recording', got '%s'", info.BackendVersion)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	expected := []string{"Init", "HealthCheck", "Protect:SSN_Internal", "Terminate"}
+	if len(backend.calls) != len(expected) {
+		t.Fatalf("Expected calls %v, got %v", expected, backend.calls)
+	}
+	for i := range expected {
+		if backend.calls[i] != expected[i] {
+			t.Errorf("Call %d: expected %s, got %s", i, expected[i], backend.calls[i])
+		}
+	}
+}
+
+func TestWithBackendNil(t *testing.T) {
+	if _, err := NewClient(newBackendTestConfig(), WithBackend(nil)); err == nil {
+		t.Error("Expected error for nil backend")
+	}
+}
+
+func TestInitializeTerminatesBackendOnFailedHealthCheck(t *testing.T) {
+	backend := &recordingBackend{healthErr: NewVoltageError(int(ErrServiceUnavailable), "down")}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	if err := client.Initialize(); !errors.Is(err, backend.healthErr) {
+		t.Fatalf("Expected health check error, got: %v", err)
+	}
+	if client.IsInitialized() {
+		t.Error("Client should not be initialized after a failed health check")
+	}
+	if last := backend.calls[len(backend.calls)-1]; last != "Terminate" {
+		t.Errorf("Expected backend to be terminated, last call was %s", last)
+	}
+}
+
+func TestMultipleMockBackends(t *testing.T) {
+	// Each MockBackend owns its state, so several clients can be initialized at once
+	clients := make([]*Client, 3)
+	for i := range clients {
+		client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if err := client.Initialize(); err != nil {
+			t.Fatalf("Failed to initialize client %d: %v", i, err)
+		}
+		defer client.Close()
+		clients[i] = client
+	}
+
+	ctx := context.Background()
+	protected, err := clients[0].ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+
+	// Same keys, same deterministic FPE output
+	for _, client := range clients[1:] {
+		accessed, err := client.AccessText(ctx, "", protected)
+		if err != nil || accessed != "123-45-6789" {
+			t.Errorf("Expected '123-45-6789', got '%s' (%v)", accessed, err)
+		}
+	}
+}
+
+func TestMockBackendLifecycle(t *testing.T) {
+	backend := NewMockBackend()
+	cfg := newBackendTestConfig()
+
+	if err := backend.HealthCheck(); !errors.Is(err, ErrClientNotInitialized) {
+		t.Errorf("Expected ErrClientNotInitialized, got: %v", err)
+	}
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if err := backend.Init(cfg); !errors.Is(err, ErrClientAlreadyInitialized) {
+		t.Errorf("Expected ErrClientAlreadyInitialized, got: %v", err)
+	}
+	if err := backend.Terminate(); err != nil {
+		t.Errorf("Terminate failed: %v", err)
+	}
+	if err := backend.Terminate(); err != nil {
+		t.Errorf("Double terminate should not error: %v", err)
+	}
+
+	cfg.XMLConfigPath = "/nonexistent/vsconfig.xml"
+	var voltageErr *VoltageError
+	if err := backend.Init(cfg); !errors.As(err, &voltageErr) || voltageErr.Code != ErrConfigNotFound {
+		t.Errorf("Expected ErrConfigNotFound for missing XML, got: %v", err)
+	}
+}
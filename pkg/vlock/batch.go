@@ -0,0 +1,171 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultBatchSize is the number of values sent to the backend in one call by ProtectBatch
+// and AccessBatch
+const DefaultBatchSize = 1000
+
+// BatchResult is the outcome of one value of a batch operation
+type BatchResult struct {
+	Value      string // Protected or accessed value, empty if Err is set
+	KeyVersion string // Key version that protected Value, set by ProtectBatch for cryptIDs with a <keyVersion>
+	Err        error
+}
+
+// BatchBackend is implemented by backends that can process many values in one call
+// The client falls back to one Protect/Access call per value for other backends
+type BatchBackend interface {
+	// ProtectBatch encrypts values with the given cryptID
+	// It returns one result per value; the error is for failures of the batch as a whole
+	ProtectBatch(cryptID string, values []string) ([]BatchResult, error)
+	// AccessBatch decrypts values with the given cryptID
+	AccessBatch(cryptID string, values []string) ([]BatchResult, error)
+}
+
+// WithBatchSize sets how many values ProtectBatch and AccessBatch send to the backend per call
+func WithBatchSize(size int) ClientOption {
+	return func(c *Client) error {
+		if size <= 0 {
+			return fmt.Errorf("batch size must be positive")
+		}
+		c.batchSize = size
+		return nil
+	}
+}
+
+// ProtectBatch encrypts values using the given cryptID
+// If cryptID is empty, Config.DefaultCryptID is used
+// Values are sent to the backend in calls of up to DefaultBatchSize values (see WithBatchSize),
+// each bounded by the deadline and retried per the client's RetryPolicy on its own
+// The returned slice has one result per value, in order; a value that cannot be protected
+// only fails its own result. The error is set when a backend call fails as a whole
+// Failed items are not retried: callers may resubmit the values whose Err is a *VoltageError
+// reporting IsRetryable
+func (c *Client) ProtectBatch(ctx context.Context, cryptID string, values []string) ([]BatchResult, error) {
+	ctx, start := c.startOperation(ctx, AuditProtectBatch)
+	results, err := c.batchOperation(ctx, cryptID, values, true)
+	c.observe(ctx, batchEvent(start, AuditProtectBatch, cryptID, len(values), results, err))
+	return results, err
+}
+
+// AccessBatch decrypts values previously produced by ProtectBatch or ProtectText with the same cryptID
+// Results and errors are reported as for ProtectBatch
+// Keys are picked as for AccessText; values tagged with a previous key version are decrypted
+// one at a time outside the batch call
+func (c *Client) AccessBatch(ctx context.Context, cryptID string, values []string) ([]BatchResult, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessBatch)
+	results, err := c.batchOperation(ctx, cryptID, values, false)
+	c.observe(ctx, batchEvent(start, AuditAccessBatch, cryptID, len(values), results, err))
+	return results, err
+}
+
+// batchOperation validates a batch request and runs it on the backend
+// Empty values are rejected individually and never reach the backend
+func (c *Client) batchOperation(ctx context.Context, cryptID string, values []string, protect bool) ([]BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkFormat(cryptID, false); err != nil {
+		return nil, err
+	}
+
+	op, emptyMessage := "access batch", "ciphertext cannot be empty"
+	if protect {
+		op, emptyMessage = "protect batch", "plaintext cannot be empty"
+	}
+
+	results := make([]BatchResult, len(values))
+	indexes := make([]int, 0, len(values))
+	pending := make([]string, 0, len(values))
+	current := c.currentKeyVersion(cryptID)
+	for i, value := range values {
+		if value == "" {
+			results[i].Err = NewVoltageError(int(ErrInvalidData), emptyMessage)
+			continue
+		}
+		if !protect {
+			keyVersion, untagged, err := c.ciphertextKeyVersion(cryptID, "", value)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			// accessLocked also reports a tag without ciphertext
+			if keyVersion != current || untagged == "" {
+				results[i].Value, results[i].Err = c.accessLocked(ctx, cryptID, keyVersion, untagged)
+				continue
+			}
+			value = untagged
+		}
+		indexes = append(indexes, i)
+		pending = append(pending, value)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	size := c.batchSize
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+	processed := make([]BatchResult, 0, len(pending))
+	for len(processed) < len(pending) {
+		chunk := pending[len(processed):min(len(processed)+size, len(pending))]
+		out, err := c.batchCall(ctx, op, cryptID, chunk, protect)
+		if err != nil {
+			return nil, err
+		}
+		if len(out) != len(chunk) {
+			return nil, NewVoltageError(int(ErrUnknown), "backend returned the wrong number of batch results")
+		}
+		processed = append(processed, out...)
+	}
+
+	for i, result := range processed {
+		if protect && result.Err == nil {
+			result.Value, result.KeyVersion = c.tagKeyVersion(current, result.Value), current
+		}
+		results[indexes[i]] = result
+	}
+	return results, nil
+}
+
+// batchCall runs one backend call for values, falling back to one call per value for
+// backends without BatchBackend
+// c.mu must be held
+func (c *Client) batchCall(ctx context.Context, op, cryptID string, values []string, protect bool) ([]BatchResult, error) {
+	backend := c.backend
+	return invoke(ctx, c, op, func() ([]BatchResult, error) {
+		if batcher, ok := backend.(BatchBackend); ok {
+			if protect {
+				return batcher.ProtectBatch(cryptID, values)
+			}
+			return batcher.AccessBatch(cryptID, values)
+		}
+
+		out := make([]BatchResult, len(values))
+		for i, value := range values {
+			if protect {
+				out[i].Value, out[i].Err = backend.Protect(cryptID, value)
+			} else {
+				out[i].Value, out[i].Err = backend.Access(cryptID, value)
+			}
+		}
+		return out, nil
+	}, nil)
+}
@@ -0,0 +1,142 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestProtectAccessBatch(t *testing.T) {
+	client := newBytesTestClient(t)
+	ctx := context.Background()
+
+	values := []string{"123-45-6789", "", "12", "987-65-4321"}
+	protected, err := client.ProtectBatch(ctx, "SSN_Internal", values)
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	if len(protected) != len(values) {
+		t.Fatalf("Expected %d results, got %d", len(values), len(protected))
+	}
+
+	// Bad values fail individually
+	var voltageErr *VoltageError
+	for _, i := range []int{1, 2} {
+		if !errors.As(protected[i].Err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+			t.Errorf("Value %d: expected ErrInvalidData, got: %v", i, protected[i].Err)
+		}
+	}
+
+	// Good values match the single-value API
+	for _, i := range []int{0, 3} {
+		if protected[i].Err != nil {
+			t.Fatalf("Value %d failed: %v", i, protected[i].Err)
+		}
+		single, err := client.ProtectText(ctx, "SSN_Internal", values[i])
+		if err != nil || single != protected[i].Value {
+			t.Errorf("Value %d: batch gave %s, ProtectText gave %s (%v)", i, protected[i].Value, single, err)
+		}
+	}
+
+	accessed, err := client.AccessBatch(ctx, "SSN_Internal", []string{protected[0].Value, protected[3].Value})
+	if err != nil {
+		t.Fatalf("AccessBatch failed: %v", err)
+	}
+	if accessed[0].Value != values[0] || accessed[1].Value != values[3] {
+		t.Errorf("Unexpected round trip: %+v", accessed)
+	}
+
+	if _, err := client.ProtectBatch(ctx, "BINARY_Internal", values); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for a BINARY cryptID, got: %v", err)
+	}
+
+	empty, err := client.ProtectBatch(ctx, "", nil)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Expected empty result for empty batch, got %v (%v)", empty, err)
+	}
+}
+
+func TestBatchFallsBackToSingleCalls(t *testing.T) {
+	backend := &recordingBackend{}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	results, err := client.ProtectBatch(context.Background(), "", []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	if results[0].Value != "p(a)" || results[1].Value != "p(b)" {
+		t.Errorf("Unexpected results %+v", results)
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	protects := 0
+	for _, call := range backend.calls {
+		if call == "Protect:SSN_Internal" {
+			protects++
+		}
+	}
+	if protects != 2 {
+		t.Errorf("Expected 2 Protect calls, got calls %v", backend.calls)
+	}
+}
+
+func TestBatchRequiresInitializedClient(t *testing.T) {
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if _, err := client.ProtectBatch(context.Background(), "", []string{"123456789"}); !errors.Is(err, ErrClientNotInitialized) {
+		t.Errorf("Expected ErrClientNotInitialized, got: %v", err)
+	}
+}
+
+// batchSizeBackend records the number of values in each ProtectBatch call
+type batchSizeBackend struct {
+	*MockBackend
+	sizes []int
+}
+
+func (b *batchSizeBackend) ProtectBatch(cryptID string, values []string) ([]BatchResult, error) {
+	b.sizes = append(b.sizes, len(values))
+	return b.MockBackend.ProtectBatch(cryptID, values)
+}
+
+func TestBatchSize(t *testing.T) {
+	if _, err := NewClient(newBackendTestConfig(), WithBatchSize(0)); err == nil {
+		t.Error("Expected an error for batch size 0")
+	}
+
+	backend := &batchSizeBackend{MockBackend: NewMockBackend()}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithBatchSize(2))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	values := []string{"123-45-6789", "", "234-56-7890", "345-67-8901", "456-78-9012", "567-89-0123"}
+	results, err := client.ProtectBatch(context.Background(), "SSN_Internal", values)
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	if fmt.Sprint(backend.sizes) != "[2 2 1]" {
+		t.Errorf("Expected backend calls of 2, 2 and 1 values, got %v", backend.sizes)
+	}
+	for i, value := range values[2:] {
+		single, err := client.ProtectText(context.Background(), "SSN_Internal", value)
+		if err != nil || results[i+2].Value != single {
+			t.Errorf("Value %d: batch gave %s, ProtectText gave %s (%v)", i+2, results[i+2].Value, single, err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// FormatBinary is the vsconfig.xml format of cryptIDs that protect raw bytes
+const FormatBinary = "BINARY"
+
+// ProtectBytes encrypts binary data using a cryptID declared with format="BINARY"
+// If cryptID is empty, Config.DefaultCryptID is used
+// Text cryptIDs are rejected with ErrInvalidData, as are cryptIDs whose format is unknown
+// because Config.XMLConfigPath is not set
+func (c *Client) ProtectBytes(ctx context.Context, cryptID string, data []byte) ([]byte, error) {
+	ctx, start := c.startOperation(ctx, AuditProtectBytes)
+	protected, _, err := c.protectBytes(ctx, cryptID, data)
+	c.observe(ctx, operationEvent{start: start, op: AuditProtectBytes, cryptID: cryptID, count: 1, err: err})
+	return protected, err
+}
+
+// ProtectBytesVersioned is ProtectBytes that also returns the key version that protected the
+// data, empty for cryptIDs without a <keyVersion>; see ProtectTextVersioned
+func (c *Client) ProtectBytesVersioned(ctx context.Context, cryptID string, data []byte) (protected []byte, keyVersion string, err error) {
+	ctx, start := c.startOperation(ctx, AuditProtectBytes)
+	protected, keyVersion, err = c.protectBytes(ctx, cryptID, data)
+	c.observe(ctx, operationEvent{start: start, op: AuditProtectBytes, cryptID: cryptID, count: 1, err: err})
+	return protected, keyVersion, err
+}
+
+// AccessBytes decrypts data previously produced by ProtectBytes with the same cryptID
+// The same format rules as ProtectBytes apply; the current key is used
+func (c *Client) AccessBytes(ctx context.Context, cryptID string, data []byte) ([]byte, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessBytes)
+	plaintext, err := c.accessBytes(ctx, cryptID, "", data)
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessBytes, cryptID: cryptID, count: 1, err: err})
+	return plaintext, err
+}
+
+// AccessBytesVersion decrypts data protected with the given key version of the cryptID,
+// current or previous; an empty keyVersion selects the current key like AccessBytes
+func (c *Client) AccessBytesVersion(ctx context.Context, cryptID, keyVersion string, data []byte) ([]byte, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessBytes)
+	plaintext, err := c.accessBytes(ctx, cryptID, keyVersion, data)
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessBytes, cryptID: cryptID, count: 1, err: err})
+	return plaintext, err
+}
+
+// protectBytes is ProtectBytesVersioned without auditing
+func (c *Client) protectBytes(ctx context.Context, cryptID string, data []byte) ([]byte, string, error) {
+	return c.bytesOperation(ctx, cryptID, "", data, true)
+}
+
+// accessBytes is AccessBytesVersion without auditing
+func (c *Client) accessBytes(ctx context.Context, cryptID, keyVersion string, data []byte) ([]byte, error) {
+	plaintext, _, err := c.bytesOperation(ctx, cryptID, keyVersion, data, false)
+	return plaintext, err
+}
+
+// bytesOperation validates a binary request and runs it on the backend
+// Access uses the given keyVersion, or the current key if it is empty; the current key
+// version is returned with the result
+func (c *Client) bytesOperation(ctx context.Context, cryptID, keyVersion string, data []byte, protect bool) ([]byte, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return nil, "", ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := c.checkFormat(cryptID, true); err != nil {
+		return nil, "", err
+	}
+
+	if len(data) == 0 {
+		return nil, "", NewVoltageError(int(ErrInvalidData), "data cannot be empty")
+	}
+
+	backend, ok := c.backend.(BytesBackend)
+	if !ok {
+		return nil, "", NewVoltageError(int(ErrInvalidParameter), fmt.Sprintf("backend %s does not support binary data", c.backend.Version()))
+	}
+
+	op := "access"
+	var previous KeyVersionBackend
+	if protect {
+		op = "protect"
+	} else if previous, err = c.previousKeyBackend(cryptID, keyVersion); err != nil {
+		return nil, "", err
+	}
+
+	result, err := invoke(ctx, c, op, func() ([]byte, error) {
+		switch {
+		case protect:
+			return backend.ProtectBytes(cryptID, data)
+		case previous != nil:
+			return previous.AccessBytesVersion(cryptID, keyVersion, data)
+		default:
+			return backend.AccessBytes(cryptID, data)
+		}
+	}, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, c.currentKeyVersion(cryptID), nil
+}
+
+// checkFormat verifies that cryptID's declared format suits a binary or text operation
+// Text operations are only refused for BINARY cryptIDs, so cryptIDs missing from
+// vsconfig.xml keep working as before and are left to the backend
+func (c *Client) checkFormat(cryptID string, binary bool) error {
+	var spec *config.CryptID
+	if c.security != nil {
+		spec, _ = c.security.CryptID(cryptID)
+	}
+
+	if !binary {
+		if spec != nil && isBinaryFormat(spec.Format) {
+			return NewVoltageError(int(ErrInvalidData),
+				fmt.Sprintf("cryptId %s has format %s; use ProtectBytes/AccessBytes", cryptID, spec.Format))
+		}
+		return nil
+	}
+
+	switch {
+	case c.security == nil:
+		return NewVoltageError(int(ErrInvalidData),
+			fmt.Sprintf("format of cryptId %s is unknown; binary operations need XMLConfigPath", cryptID))
+	case spec == nil:
+		return NewVoltageError(int(ErrCryptIDNotFound), fmt.Sprintf("cryptId %s is not defined in %s", cryptID, c.security.Path))
+	case !isBinaryFormat(spec.Format):
+		return NewVoltageError(int(ErrInvalidData),
+			fmt.Sprintf("cryptId %s has text format %s; binary data needs a %s cryptId", cryptID, spec.Format, FormatBinary))
+	}
+	return nil
+}
+
+// isBinaryFormat reports whether a vsconfig.xml format attribute denotes binary data
+func isBinaryFormat(format string) bool {
+	return strings.EqualFold(format, FormatBinary)
+}
+
+// loadSecurity reads the client's vsconfig.xml, or returns nil if none is configured
+// It remembers the <audit> policy read; c.mu must be held
+func (c *Client) loadSecurity() (*config.SecurityConfig, error) {
+	if c.config.XMLConfigPath == "" {
+		return nil, nil
+	}
+	security, err := loadSecurityConfig(c.config.XMLConfigPath)
+	if err == nil {
+		c.rememberAuditPolicyLocked(security)
+	}
+	return security, err
+}
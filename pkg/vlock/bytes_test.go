@@ -0,0 +1,128 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func newBytesTestClient(t *testing.T) *Client {
+	t.Helper()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestProtectAccessBytes(t *testing.T) {
+	client := newBytesTestClient(t)
+	ctx := context.Background()
+
+	data := []byte{0x00, 0x10, 0x80, 0xff, 0x00}
+	protected, err := client.ProtectBytes(ctx, "BINARY_Internal", data)
+	if err != nil {
+		t.Fatalf("ProtectBytes failed: %v", err)
+	}
+	if bytes.Contains(protected, data) {
+		t.Error("Protected data contains the plaintext")
+	}
+
+	accessed, err := client.AccessBytes(ctx, "BINARY_Internal", protected)
+	if err != nil {
+		t.Fatalf("AccessBytes failed: %v", err)
+	}
+	if !bytes.Equal(accessed, data) {
+		t.Errorf("Expected %x, got %x", data, accessed)
+	}
+
+	protected[len(protected)-1] ^= 1
+	var voltageErr *VoltageError
+	if _, err := client.AccessBytes(ctx, "BINARY_Internal", protected); !errors.As(err, &voltageErr) || voltageErr.Code != ErrDecryptionFailed {
+		t.Errorf("Expected ErrDecryptionFailed for tampered data, got: %v", err)
+	}
+}
+
+func TestBytesFormatEnforcement(t *testing.T) {
+	client := newBytesTestClient(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		code ErrorCode
+	}{
+		{"FPE cryptID", func() error {
+			_, err := client.ProtectBytes(ctx, "SSN_Internal", []byte("123456789"))
+			return err
+		}, ErrInvalidData},
+		{"BASE64 cryptID", func() error {
+			_, err := client.AccessBytes(ctx, "TEXT_Internal", []byte("data"))
+			return err
+		}, ErrInvalidData},
+		{"default text cryptID", func() error {
+			_, err := client.ProtectBytes(ctx, "", []byte("data"))
+			return err
+		}, ErrInvalidData},
+		{"undefined cryptID", func() error {
+			_, err := client.ProtectBytes(ctx, "NOPE_Internal", []byte("data"))
+			return err
+		}, ErrCryptIDNotFound},
+		{"empty data", func() error {
+			_, err := client.ProtectBytes(ctx, "BINARY_Internal", nil)
+			return err
+		}, ErrInvalidData},
+		{"text on BINARY cryptID", func() error {
+			_, err := client.ProtectText(ctx, "BINARY_Internal", "data")
+			return err
+		}, ErrInvalidData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var voltageErr *VoltageError
+			if err := tt.call(); !errors.As(err, &voltageErr) || voltageErr.Code != tt.code {
+				t.Errorf("Expected error code %d, got: %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestBytesRequireFormatsAndBackendSupport(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = ""
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	var voltageErr *VoltageError
+	if _, err := client.ProtectBytes(ctx, "BINARY_Internal", []byte("data")); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData without XMLConfigPath, got: %v", err)
+	}
+
+	// recordingBackend only implements the text operations
+	textOnly, err := NewClient(newBackendTestConfig(), WithBackend(&recordingBackend{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := textOnly.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer textOnly.Close()
+
+	if _, err := textOnly.ProtectBytes(ctx, "BINARY_Internal", []byte("data")); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidParameter {
+		t.Errorf("Expected ErrInvalidParameter for a text-only backend, got: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the client's circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Calls flow normally
+	CircuitOpen                         // Calls fail fast with ErrCircuitOpen
+	CircuitHalfOpen                     // A limited number of probe calls are let through
+)
+
+// String returns the state name
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// CircuitBreakerConfig configures the circuit breaker around backend calls
+// Only transient failures (see VoltageError.IsRetryable) count against the service;
+// errors such as invalid input mean the service answered and count as successes
+type CircuitBreakerConfig struct {
+	FailureThreshold float64       // Failure rate in (0, 1] at which the circuit opens
+	MinRequests      int           // Calls needed in a window before the rate is evaluated
+	Window           time.Duration // Length of the window the failure rate is measured over
+	CoolDown         time.Duration // Time spent open before probe calls are allowed
+	HalfOpenProbes   int           // Concurrent probe calls allowed while half-open, defaults to 1
+}
+
+// DefaultCircuitBreakerConfig returns a breaker that opens at a 50% failure rate
+// over at least 10 calls in 30s, and probes again after 10s
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 0.5,
+		MinRequests:      10,
+		Window:           30 * time.Second,
+		CoolDown:         10 * time.Second,
+		HalfOpenProbes:   1,
+	}
+}
+
+// ErrCircuitOpen is returned without calling the backend while the circuit is open
+var ErrCircuitOpen = &VoltageError{
+	Code:    ErrServiceUnavailable,
+	Message: "circuit breaker open",
+	Detail:  "recent Voltage calls failed; retry after the cool-down",
+}
+
+// WithCircuitBreaker wraps backend calls in a circuit breaker
+func WithCircuitBreaker(cfg CircuitBreakerConfig) ClientOption {
+	return func(c *Client) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		c.breaker = newCircuitBreaker(cfg)
+		return nil
+	}
+}
+
+// validate checks the breaker configuration
+func (cfg CircuitBreakerConfig) validate() error {
+	switch {
+	case cfg.FailureThreshold <= 0 || cfg.FailureThreshold > 1:
+		return fmt.Errorf("circuit breaker: FailureThreshold must be in (0, 1]")
+	case cfg.MinRequests < 1:
+		return fmt.Errorf("circuit breaker: MinRequests must be at least 1")
+	case cfg.Window <= 0 || cfg.CoolDown <= 0:
+		return fmt.Errorf("circuit breaker: Window and CoolDown must be positive")
+	case cfg.HalfOpenProbes < 0:
+		return fmt.Errorf("circuit breaker: HalfOpenProbes cannot be negative")
+	}
+	return nil
+}
+
+// circuitBreaker tracks call outcomes and decides whether calls may proceed
+type circuitBreaker struct {
+	cfg CircuitBreakerConfig
+	now func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // Probe calls in flight while half-open
+}
+
+func newCircuitBreaker(cfg CircuitBreakerConfig) *circuitBreaker {
+	if cfg.HalfOpenProbes == 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// State returns the current state, moving from open to half-open once the cool-down has passed
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked()
+	return b.state
+}
+
+// advanceLocked applies time-based transitions
+func (b *circuitBreaker) advanceLocked() {
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.cfg.CoolDown {
+		b.state = CircuitHalfOpen
+		b.probes = 0
+	}
+}
+
+// allow reports whether a call may proceed; each allowed call must be followed by record
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advanceLocked()
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// record accounts for the outcome of an allowed call and returns the resulting state,
+// reporting whether the call changed it
+func (b *circuitBreaker) record(err error) (CircuitState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The caller gave up; that says nothing about the service
+	if errors.Is(err, context.Canceled) {
+		if b.state == CircuitHalfOpen && b.probes > 0 {
+			b.probes--
+		}
+		return b.state, false
+	}
+	failed := isRetryable(err)
+	before := b.state
+
+	switch b.state {
+	case CircuitHalfOpen:
+		if failed {
+			b.openLocked()
+		} else {
+			b.closeLocked()
+		}
+	case CircuitClosed:
+		now := b.now()
+		if now.Sub(b.windowStart) >= b.cfg.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= b.cfg.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= b.cfg.FailureThreshold {
+			b.openLocked()
+		}
+	}
+	// Outcomes of calls that started before the circuit opened are ignored
+	return b.state, b.state != before
+}
+
+func (b *circuitBreaker) openLocked() {
+	b.state = CircuitOpen
+	b.openedAt = b.now()
+	b.probes = 0
+}
+
+func (b *circuitBreaker) closeLocked() {
+	b.state = CircuitClosed
+	b.windowStart = b.now()
+	b.requests, b.failures = 0, 0
+	b.probes = 0
+}
@@ -0,0 +1,184 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for breaker tests
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(cfg CircuitBreakerConfig) (*circuitBreaker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	b := newCircuitBreaker(cfg)
+	b.now = clock.now
+	b.windowStart = clock.t
+	return b, clock
+}
+
+var errTransient = NewVoltageError(int(ErrConnectionFailed), "down")
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	b, clock := newTestBreaker(CircuitBreakerConfig{
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		CoolDown:         5 * time.Second,
+	})
+
+	// Below MinRequests the rate is not evaluated
+	for i := 0; i < 3; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatalf("Call %d rejected while closed: %v", i, err)
+		}
+		b.record(errTransient)
+	}
+	if b.State() != CircuitClosed {
+		t.Fatalf("Expected closed below MinRequests, got %v", b.State())
+	}
+
+	b.allow()
+	b.record(errTransient)
+	if b.State() != CircuitOpen {
+		t.Fatalf("Expected open after 4/4 failures, got %v", b.State())
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen while open, got %v", err)
+	}
+
+	clock.advance(5 * time.Second)
+	if b.State() != CircuitHalfOpen {
+		t.Fatalf("Expected half-open after cool-down, got %v", b.State())
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("Probe rejected: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Error("Only one probe should be allowed while half-open")
+	}
+
+	// A failed probe reopens the circuit for another cool-down
+	b.record(errTransient)
+	if b.State() != CircuitOpen {
+		t.Fatalf("Expected open after failed probe, got %v", b.State())
+	}
+
+	clock.advance(5 * time.Second)
+	b.allow()
+	b.record(nil)
+	if b.State() != CircuitClosed {
+		t.Fatalf("Expected closed after successful probe, got %v", b.State())
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	b, clock := newTestBreaker(CircuitBreakerConfig{
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		CoolDown:         time.Second,
+	})
+
+	// Non-transient errors mean the service answered
+	for i := 0; i < 10; i++ {
+		b.allow()
+		b.record(NewVoltageError(int(ErrInvalidData), "bad input"))
+	}
+	b.allow()
+	b.record(context.Canceled)
+	if b.State() != CircuitClosed {
+		t.Fatalf("Non-transient errors should not open the circuit, got %v", b.State())
+	}
+
+	// 3 failures out of 13 stays below 50%
+	for i := 0; i < 3; i++ {
+		b.allow()
+		b.record(errTransient)
+	}
+	if b.State() != CircuitClosed {
+		t.Fatalf("Expected closed below threshold, got %v", b.State())
+	}
+
+	// A new window forgets old successes
+	clock.advance(time.Minute)
+	for i := 0; i < 4; i++ {
+		b.allow()
+		b.record(errTransient)
+	}
+	if b.State() != CircuitOpen {
+		t.Fatalf("Expected open in a fresh window, got %v", b.State())
+	}
+}
+
+func TestCircuitBreakerConfigValidation(t *testing.T) {
+	bad := []CircuitBreakerConfig{
+		{FailureThreshold: 0, MinRequests: 1, Window: time.Second, CoolDown: time.Second},
+		{FailureThreshold: 1.5, MinRequests: 1, Window: time.Second, CoolDown: time.Second},
+		{FailureThreshold: 0.5, MinRequests: 0, Window: time.Second, CoolDown: time.Second},
+		{FailureThreshold: 0.5, MinRequests: 1, CoolDown: time.Second},
+		{FailureThreshold: 0.5, MinRequests: 1, Window: time.Second},
+		{FailureThreshold: 0.5, MinRequests: 1, Window: time.Second, CoolDown: time.Second, HalfOpenProbes: -1},
+	}
+	for i, cfg := range bad {
+		if _, err := NewClient(newBackendTestConfig(), WithCircuitBreaker(cfg)); err == nil {
+			t.Errorf("Config %d: expected validation error", i)
+		}
+	}
+	if _, err := NewClient(newBackendTestConfig(), WithCircuitBreaker(DefaultCircuitBreakerConfig())); err != nil {
+		t.Errorf("Default config rejected: %v", err)
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	backend := &flakyBackend{failures: 1 << 30, failWith: errTransient}
+	backend.MockBackend = NewMockBackend()
+	client, err := NewClient(newBackendTestConfig(),
+		WithBackend(backend),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond}),
+		WithCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 0.6,
+			MinRequests:      5,
+			Window:           time.Minute,
+			CoolDown:         time.Hour,
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if got := client.Info().CircuitState; got != CircuitClosed {
+		t.Fatalf("Expected closed circuit, got %v", got)
+	}
+
+	// Init and the initial health check succeeded, so the third failure
+	// reaches 3/5 and opens the circuit; retries stop there
+	_, err = client.ProtectText(context.Background(), "", "123-45-6789")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got: %v", err)
+	}
+	if got := backend.protects.Load(); got != 3 {
+		t.Errorf("Expected 3 backend calls before opening, got %d", got)
+	}
+
+	_, err = client.ProtectText(context.Background(), "", "123-45-6789")
+	var voltageErr *VoltageError
+	if !errors.As(err, &voltageErr) || voltageErr.Code != ErrServiceUnavailable {
+		t.Errorf("Expected ErrServiceUnavailable fast-fail, got: %v", err)
+	}
+	if got := backend.protects.Load(); got != 3 {
+		t.Errorf("Backend should not be called while open, got %d calls", got)
+	}
+
+	if got := client.Info().CircuitState; got != CircuitOpen {
+		t.Errorf("Expected open circuit in ClientInfo, got %v", got)
+	}
+}
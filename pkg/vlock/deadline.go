@@ -0,0 +1,96 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// networkTimeout returns Config.NetworkTimeout as a duration, or 0 when unset
+func (c *Client) networkTimeout() time.Duration {
+	if c.config == nil || c.config.NetworkTimeout <= 0 {
+		return 0
+	}
+	return time.Duration(c.config.NetworkTimeout) * time.Second
+}
+
+// withNetworkTimeout applies Config.NetworkTimeout as the deadline unless ctx already has one
+func (c *Client) withNetworkTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	if timeout := c.networkTimeout(); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// contextError converts a context error into the error returned to callers
+// Expired deadlines become ErrNetworkTimeout-coded *VoltageError values that still
+// match context.DeadlineExceeded with errors.Is; cancellation is returned as-is
+func contextError(op string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &VoltageError{
+			Code:    ErrNetworkTimeout,
+			Message: "network operation timed out",
+			Detail:  fmt.Sprintf("%s did not complete before the deadline", op),
+			cause:   err,
+		}
+	}
+	return err
+}
+
+// callContext runs fn on its own goroutine and waits for it or for ctx to end
+// Backend calls cannot be interrupted, so when ctx ends first fn keeps running and
+// abandon (if not nil) receives its eventual result, letting callers undo side effects
+func callContext[T any](ctx context.Context, op string, fn func() (T, error), abandon func(T, error)) (T, error) {
+	value, err, _ := callContextSettled(ctx, op, fn, abandon)
+	return value, err
+}
+
+// callContextSettled is callContext that also returns, for an abandoned call, a channel
+// closed once fn has returned and abandon has run; it is nil when the call was not abandoned
+func callContextSettled[T any](ctx context.Context, op string, fn func() (T, error), abandon func(T, error)) (T, error, <-chan struct{}) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, contextError(op, err), nil
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err, nil
+	case <-ctx.Done():
+		settled := make(chan struct{})
+		go func() {
+			defer close(settled)
+			r := <-done
+			if abandon != nil {
+				abandon(r.value, r.err)
+			}
+		}()
+		return zero, contextError(op, ctx.Err()), settled
+	}
+}
+
+// runContext is callContext for functions that only return an error
+func runContext(ctx context.Context, op string, fn func() error, abandon func(error)) error {
+	var onAbandon func(struct{}, error)
+	if abandon != nil {
+		onAbandon = func(_ struct{}, err error) { abandon(err) }
+	}
+	_, err := callContext(ctx, op, func() (struct{}, error) {
+		return struct{}{}, fn()
+	}, onAbandon)
+	return err
+}
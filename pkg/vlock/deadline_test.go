@@ -0,0 +1,155 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// blockingBackend wraps MockBackend and blocks selected calls until released
+type blockingBackend struct {
+	*MockBackend
+	blockInit   chan struct{}
+	blockHealth chan struct{}
+	terminated  atomic.Int32
+}
+
+func newBlockingBackend() *blockingBackend {
+	return &blockingBackend{MockBackend: NewMockBackend()}
+}
+
+func (b *blockingBackend) Init(cfg *config.Config) error {
+	if b.blockInit != nil {
+		<-b.blockInit
+	}
+	return b.MockBackend.Init(cfg)
+}
+
+func (b *blockingBackend) HealthCheck() error {
+	if b.blockHealth != nil {
+		<-b.blockHealth
+	}
+	return b.MockBackend.HealthCheck()
+}
+
+func (b *blockingBackend) Terminate() error {
+	b.terminated.Add(1)
+	return b.MockBackend.Terminate()
+}
+
+func assertTimeout(t *testing.T, err error) {
+	t.Helper()
+	var voltageErr *VoltageError
+	if !errors.As(err, &voltageErr) || voltageErr.Code != ErrNetworkTimeout {
+		t.Fatalf("Expected ErrNetworkTimeout, got: %v", err)
+	}
+	if !voltageErr.IsRetryable() {
+		t.Error("Timeout errors should be retryable")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Timeout errors should match context.DeadlineExceeded")
+	}
+}
+
+func TestInitializeContextDeadline(t *testing.T) {
+	backend := newBlockingBackend()
+	backend.blockInit = make(chan struct{})
+
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assertTimeout(t, client.InitializeContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("InitializeContext should return at the deadline, took %v", elapsed)
+	}
+	if client.IsInitialized() {
+		t.Error("Client should not be initialized after a timeout")
+	}
+
+	// When the blocked Init finally succeeds, it is rolled back
+	close(backend.blockInit)
+	deadline := time.Now().Add(time.Second)
+	for backend.terminated.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if backend.terminated.Load() == 0 {
+		t.Error("Expected late Init to be terminated")
+	}
+
+	// The client can be initialized normally afterwards
+	if err := client.InitializeContext(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize after timeout: %v", err)
+	}
+	client.Close()
+}
+
+func TestHealthCheckContextUsesNetworkTimeout(t *testing.T) {
+	backend := newBlockingBackend()
+	cfg := newBackendTestConfig()
+	cfg.NetworkTimeout = 1
+
+	client, err := NewClient(cfg, WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	backend.blockHealth = make(chan struct{})
+	defer close(backend.blockHealth)
+
+	// No deadline on the context, so NetworkTimeout (1s) applies
+	start := time.Now()
+	assertTimeout(t, client.HealthCheck())
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("Expected health check to time out after ~1s, took %v", elapsed)
+	}
+	if client.IsHealthy() {
+		t.Error("Client should be unhealthy after a timed out health check")
+	}
+}
+
+func TestContextCancellation(t *testing.T) {
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := client.InitializeContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+
+	if err := client.InitializeContext(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if err := client.HealthCheckContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if err := client.ReinitializeContext(context.Background()); err != nil {
+		t.Errorf("ReinitializeContext failed: %v", err)
+	}
+	if err := client.CloseContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+	if !client.IsInitialized() {
+		t.Error("Client should stay initialized when Close is cancelled")
+	}
+	if err := client.CloseContext(context.Background()); err != nil {
+		t.Errorf("CloseContext failed: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package vlock
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -39,6 +40,8 @@ type VoltageError struct {
 	Message string
 	Detail  string
 	CError  int // Original C error code
+
+	cause error // Underlying Go error, if any
 }
 
 // Error implements the error interface
@@ -58,6 +61,11 @@ func (e *VoltageError) Is(target error) bool {
 	return e.Code == t.Code
 }
 
+// Unwrap returns the underlying Go error, such as context.DeadlineExceeded for timeouts
+func (e *VoltageError) Unwrap() error {
+	return e.cause
+}
+
 // IsRetryable returns true if the error is transient and the operation can be retried
 func (e *VoltageError) IsRetryable() bool {
 	switch e.Code {
@@ -172,6 +180,15 @@ func WrapError(err error, code ErrorCode, detail string) error {
 	}
 }
 
+// errorCode returns the code of the VoltageError in err's chain
+func errorCode(err error) (ErrorCode, bool) {
+	var voltageErr *VoltageError
+	if errors.As(err, &voltageErr) {
+		return voltageErr.Code, true
+	}
+	return 0, false
+}
+
 // ErrorCategory represents a high-level category of errors
 type ErrorCategory int
 
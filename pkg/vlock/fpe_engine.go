@@ -0,0 +1,303 @@
+package vlock
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+	"github.com/daveaugustus/vlock/pkg/internal/fpe"
+)
+
+// softEngine is a pure-Go implementation of the Voltage protect/access operations
+// FPE cryptIDs use NIST FF1 (or FF3-1) so ciphertext keeps the length and alphabet of the input,
+// AES256 cryptIDs use AES-256-GCM with base64 output
+type softEngine struct {
+	ciphers  map[string]*engineCipher
+	previous map[string]map[string]*engineCipher // cryptID -> key version -> cipher of a retired key
+}
+
+// engineCipher holds the keyed primitives for a single cryptID
+type engineCipher struct {
+	spec     config.CryptID
+	fpe      fpe.Cipher
+	alphabet *fpe.Alphabet
+	aead     cipher.AEAD
+}
+
+// newSoftEngine builds an engine with one cipher per cryptID
+func newSoftEngine(cryptIDs []config.CryptID) (*softEngine, error) {
+	engine := &softEngine{
+		ciphers:  make(map[string]*engineCipher, len(cryptIDs)),
+		previous: make(map[string]map[string]*engineCipher),
+	}
+	for _, spec := range cryptIDs {
+		c, err := newEngineCipher(spec)
+		if err != nil {
+			return nil, fmt.Errorf("cryptId %s: %w", spec.Name, err)
+		}
+		engine.ciphers[spec.Name] = c
+
+		for _, prev := range spec.PreviousKeys {
+			retired := spec
+			retired.Key, retired.KeyVersion, retired.PreviousKeys = prev.Key, prev.Version, nil
+			c, err := newEngineCipher(retired)
+			if err != nil {
+				return nil, fmt.Errorf("cryptId %s key version %s: %w", spec.Name, prev.Version, err)
+			}
+			if engine.previous[spec.Name] == nil {
+				engine.previous[spec.Name] = make(map[string]*engineCipher)
+			}
+			engine.previous[spec.Name][prev.Version] = c
+		}
+	}
+	return engine, nil
+}
+
+// newEngineCipher keys the primitives for spec
+func newEngineCipher(spec config.CryptID) (*engineCipher, error) {
+	key := deriveEngineKey(spec.Key)
+	c := &engineCipher{spec: spec}
+
+	switch strings.ToUpper(spec.Algorithm) {
+	case "FPE", "FF1", "FF3-1", "FF31":
+		var alphabet string
+		switch strings.ToUpper(spec.Format) {
+		case "NUMERIC":
+			alphabet = fpe.Numeric
+		case "ALPHANUMERIC":
+			alphabet = fpe.Alphanumeric
+		default:
+			return nil, fmt.Errorf("format %q is not supported for format-preserving encryption", spec.Format)
+		}
+
+		a, err := fpe.NewAlphabet(alphabet)
+		if err != nil {
+			return nil, err
+		}
+		c.alphabet = a
+
+		if strings.HasPrefix(strings.ToUpper(spec.Algorithm), "FF3") {
+			c.fpe, err = fpe.NewFF31(key, make([]byte, fpe.FF31TweakSize), a.Radix())
+		} else {
+			c.fpe, err = fpe.NewFF1(key, nil, a.Radix())
+		}
+		if err != nil {
+			return nil, err
+		}
+
+	case "AES256", "AES":
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		c.aead, err = cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", spec.Algorithm)
+	}
+
+	return c, nil
+}
+
+// deriveEngineKey turns the key attribute into an AES key
+// Hex-encoded 128/192/256-bit keys are used as-is, anything else (including the
+// placeholder keys in the sample configs) is stretched with SHA-256
+func deriveEngineKey(key string) []byte {
+	switch len(key) {
+	case 32, 48, 64:
+		if raw, err := hex.DecodeString(key); err == nil {
+			return raw
+		}
+	}
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// cipherFor returns the cipher for cryptID
+func (e *softEngine) cipherFor(cryptID string) (*engineCipher, error) {
+	c, ok := e.ciphers[cryptID]
+	if !ok {
+		return nil, NewVoltageError(int(ErrCryptIDNotFound), fmt.Sprintf("cryptId %s is not defined", cryptID))
+	}
+	return c, nil
+}
+
+// cipherForVersion returns the cipher for a key version of cryptID, current or retired
+func (e *softEngine) cipherForVersion(cryptID, keyVersion string) (*engineCipher, error) {
+	c, err := e.cipherFor(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	if keyVersion == c.spec.KeyVersion {
+		return c, nil
+	}
+	if retired, ok := e.previous[cryptID][keyVersion]; ok {
+		return retired, nil
+	}
+	return nil, NewVoltageError(int(ErrKeyNotFound), fmt.Sprintf("cryptId %s has no key version %s", cryptID, keyVersion))
+}
+
+// protect encrypts plaintext with cryptID
+func (e *softEngine) protect(cryptID, plaintext string) (string, error) {
+	c, err := e.cipherFor(cryptID)
+	if err != nil {
+		return "", err
+	}
+
+	if c.aead != nil {
+		sealed, err := c.seal([]byte(plaintext))
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(sealed), nil
+	}
+
+	return c.transform(plaintext, true)
+}
+
+// access decrypts ciphertext with the current key of cryptID
+func (e *softEngine) access(cryptID, ciphertext string) (string, error) {
+	c, err := e.cipherFor(cryptID)
+	if err != nil {
+		return "", err
+	}
+	return c.access(ciphertext)
+}
+
+// accessVersion decrypts ciphertext with the given key version of cryptID
+func (e *softEngine) accessVersion(cryptID, keyVersion, ciphertext string) (string, error) {
+	c, err := e.cipherForVersion(cryptID, keyVersion)
+	if err != nil {
+		return "", err
+	}
+	return c.access(ciphertext)
+}
+
+// access decrypts ciphertext with this cipher
+func (c *engineCipher) access(ciphertext string) (string, error) {
+	if c.aead != nil {
+		sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+		if err != nil {
+			return "", NewVoltageError(int(ErrInvalidData), "ciphertext is not valid base64-encoded AES-GCM data")
+		}
+		plaintext, err := c.open(sealed)
+		if err != nil {
+			return "", err
+		}
+		return string(plaintext), nil
+	}
+
+	return c.transform(ciphertext, false)
+}
+
+// protectBytes encrypts binary data with an AES cryptID, returning nonce || ciphertext
+func (e *softEngine) protectBytes(cryptID string, data []byte) ([]byte, error) {
+	c, err := e.binaryCipherFor(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	return c.seal(data)
+}
+
+// accessBytes decrypts data produced by protectBytes
+func (e *softEngine) accessBytes(cryptID string, data []byte) ([]byte, error) {
+	c, err := e.binaryCipherFor(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	return c.open(data)
+}
+
+// accessBytesVersion decrypts data produced by protectBytes with the given key version of cryptID
+func (e *softEngine) accessBytesVersion(cryptID, keyVersion string, data []byte) ([]byte, error) {
+	c, err := e.cipherForVersion(cryptID, keyVersion)
+	if err != nil {
+		return nil, err
+	}
+	if c.aead == nil {
+		return nil, NewVoltageError(int(ErrInvalidData), fmt.Sprintf("cryptId %s uses %s and cannot process binary data", cryptID, c.spec.Algorithm))
+	}
+	return c.open(data)
+}
+
+// binaryCipherFor returns the cipher for cryptID if it can process arbitrary bytes
+func (e *softEngine) binaryCipherFor(cryptID string) (*engineCipher, error) {
+	c, err := e.cipherFor(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	if c.aead == nil {
+		return nil, NewVoltageError(int(ErrInvalidData), fmt.Sprintf("cryptId %s uses %s and cannot process binary data", cryptID, c.spec.Algorithm))
+	}
+	return c, nil
+}
+
+// seal encrypts data with AES-GCM under a random nonce, authenticating the cryptID name
+func (c *engineCipher) seal(data []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(data)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, NewVoltageError(int(ErrEncryptionFailed), err.Error())
+	}
+	return c.aead.Seal(nonce, nonce, data, []byte(c.spec.Name)), nil
+}
+
+// open reverses seal
+func (c *engineCipher) open(sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize()+c.aead.Overhead() {
+		return nil, NewVoltageError(int(ErrInvalidData), "ciphertext is too short for AES-GCM data")
+	}
+	nonce, body := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	data, err := c.aead.Open(nil, nonce, body, []byte(c.spec.Name))
+	if err != nil {
+		return nil, NewVoltageError(int(ErrDecryptionFailed), "ciphertext authentication failed")
+	}
+	return data, nil
+}
+
+// transform applies FPE to the characters of s that belong to the cryptID's alphabet,
+// leaving separators such as '-' or '@' in place
+func (c *engineCipher) transform(s string, encrypt bool) (string, error) {
+	runes := []rune(s)
+	positions := make([]int, 0, len(runes))
+	numerals := make([]uint16, 0, len(runes))
+	for i, r := range runes {
+		if c.alphabet.Contains(r) {
+			positions = append(positions, i)
+			numerals = append(numerals, c.alphabet.Numeral(r))
+		}
+	}
+
+	if len(numerals) < c.fpe.MinLength() {
+		return "", NewVoltageError(int(ErrInvalidData),
+			fmt.Sprintf("%s input needs at least %d %s characters", c.spec.Name, c.fpe.MinLength(), strings.ToLower(c.spec.Format)))
+	}
+
+	var out []uint16
+	var err error
+	if encrypt {
+		out, err = c.fpe.Encrypt(numerals)
+	} else {
+		out, err = c.fpe.Decrypt(numerals)
+	}
+	if err != nil {
+		code := ErrEncryptionFailed
+		if !encrypt {
+			code = ErrDecryptionFailed
+		}
+		return "", NewVoltageError(int(code), err.Error())
+	}
+
+	for i, pos := range positions {
+		runes[pos] = c.alphabet.Char(out[i])
+	}
+	return string(runes), nil
+}
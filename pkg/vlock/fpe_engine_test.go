@@ -0,0 +1,119 @@
+package vlock
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+func newTestEngine(t *testing.T) *softEngine {
+	t.Helper()
+	security, err := config.LoadSecurityConfig("../config/dev/vsconfig.xml")
+	if err != nil {
+		t.Fatalf("Failed to load cryptIds: %v", err)
+	}
+	engine, err := newSoftEngine(security.CryptIDs)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	return engine
+}
+
+func TestSoftEngineFormatPreserving(t *testing.T) {
+	engine := newTestEngine(t)
+
+	tests := []struct {
+		cryptID   string
+		plaintext string
+	}{
+		{"SSN_Internal", "123-45-6789"},
+		{"SSN_Internal", "123456789"},
+		{"CCN_Internal", "4111-1111-1111-1111"},
+		{"EMAIL_Internal", "john.smith@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cryptID+"/"+tt.plaintext, func(t *testing.T) {
+			protected, err := engine.protect(tt.cryptID, tt.plaintext)
+			if err != nil {
+				t.Fatalf("protect failed: %v", err)
+			}
+			if protected == tt.plaintext {
+				t.Error("Protected value should differ from plaintext")
+			}
+			if len(protected) != len(tt.plaintext) {
+				t.Errorf("Expected length %d, got %d (%s)", len(tt.plaintext), len(protected), protected)
+			}
+			for i := range tt.plaintext {
+				isDigit := func(b byte) bool { return b >= '0' && b <= '9' }
+				if isDigit(tt.plaintext[i]) != isDigit(protected[i]) && tt.cryptID != "EMAIL_Internal" {
+					t.Errorf("Character class changed at position %d: %s -> %s", i, tt.plaintext, protected)
+				}
+				if !isAlphanumeric(tt.plaintext[i]) && tt.plaintext[i] != protected[i] {
+					t.Errorf("Separator at position %d not preserved: %s -> %s", i, tt.plaintext, protected)
+				}
+			}
+
+			// FPE is deterministic
+			again, _ := engine.protect(tt.cryptID, tt.plaintext)
+			if again != protected {
+				t.Errorf("Expected deterministic output, got %s and %s", protected, again)
+			}
+
+			accessed, err := engine.access(tt.cryptID, protected)
+			if err != nil {
+				t.Fatalf("access failed: %v", err)
+			}
+			if accessed != tt.plaintext {
+				t.Errorf("Expected %s, got %s", tt.plaintext, accessed)
+			}
+		})
+	}
+}
+
+func isAlphanumeric(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func TestSoftEngineAES(t *testing.T) {
+	engine := newTestEngine(t)
+
+	protected, err := engine.protect("TEXT_Internal", "free-form text value")
+	if err != nil {
+		t.Fatalf("protect failed: %v", err)
+	}
+
+	accessed, err := engine.access("TEXT_Internal", protected)
+	if err != nil {
+		t.Fatalf("access failed: %v", err)
+	}
+	if accessed != "free-form text value" {
+		t.Errorf("Expected round trip, got %s", accessed)
+	}
+
+	// Ciphertext is bound to its cryptId
+	var voltageErr *VoltageError
+	if _, err := engine.access("BINARY_Internal", protected); !errors.As(err, &voltageErr) || voltageErr.Code != ErrDecryptionFailed {
+		t.Errorf("Expected ErrDecryptionFailed for wrong cryptId, got: %v", err)
+	}
+}
+
+func TestSoftEngineErrors(t *testing.T) {
+	engine := newTestEngine(t)
+
+	var voltageErr *VoltageError
+	if _, err := engine.protect("UNKNOWN", "123456789"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrCryptIDNotFound {
+		t.Errorf("Expected ErrCryptIDNotFound, got: %v", err)
+	}
+	if _, err := engine.protect("SSN_Internal", "12-34"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for short input, got: %v", err)
+	}
+
+	if _, err := newSoftEngine([]config.CryptID{{Name: "BAD", Algorithm: "ROT13"}}); err == nil {
+		t.Error("Expected error for unsupported algorithm")
+	}
+	if _, err := newSoftEngine([]config.CryptID{{Name: "BAD", Algorithm: "FPE", Format: "BINARY"}}); err == nil {
+		t.Error("Expected error for unsupported FPE format")
+	}
+}
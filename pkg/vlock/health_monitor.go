@@ -0,0 +1,266 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// HealthChangeFunc is called when the client's health flips
+// err is the error that made the client unhealthy, or nil
+type HealthChangeFunc func(oldHealthy, newHealthy bool, err error)
+
+// HealthMonitorConfig configures the background health monitor
+type HealthMonitorConfig struct {
+	Interval          time.Duration // Time between health checks
+	ReinitializeAfter int           // Consecutive failures before Reinitialize is attempted, 0 to never reinitialize; deferred while the circuit breaker is open
+}
+
+// healthEvent is a queued health transition awaiting delivery to callbacks
+type healthEvent struct {
+	oldHealthy bool
+	newHealthy bool
+	err        error
+}
+
+// healthNotifier delivers health events and key rotation warnings to callbacks in order
+// on a dedicated goroutine, so callbacks may call back into the client without deadlocking
+type healthNotifier struct {
+	mu                sync.Mutex
+	callbacks         []HealthChangeFunc
+	rotationCallbacks []KeyRotationFunc
+	queue             []func()
+	dispatching       bool
+}
+
+// healthMonitor is a running background monitor
+type healthMonitor struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// WithHealthMonitor enables the background health monitor
+// The monitor starts when the client is initialized and stops when it is closed
+func WithHealthMonitor(cfg HealthMonitorConfig) ClientOption {
+	return func(c *Client) error {
+		if err := cfg.validate(); err != nil {
+			return err
+		}
+		c.monitorConfig = &cfg
+		return nil
+	}
+}
+
+// validate checks the monitor configuration
+func (cfg HealthMonitorConfig) validate() error {
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("health monitor interval must be positive")
+	}
+	if cfg.ReinitializeAfter < 0 {
+		return fmt.Errorf("health monitor ReinitializeAfter cannot be negative")
+	}
+	return nil
+}
+
+// OnHealthChange registers a callback invoked whenever the client's health flips,
+// whether the change comes from the monitor, HealthCheck, Initialize or Close
+// Callbacks run sequentially on a separate goroutine in the order changes happened
+func (c *Client) OnHealthChange(fn HealthChangeFunc) {
+	if fn == nil {
+		return
+	}
+	c.notifier.mu.Lock()
+	defer c.notifier.mu.Unlock()
+	c.notifier.callbacks = append(c.notifier.callbacks, fn)
+}
+
+// setHealthyLocked updates the health flag and queues a notification if it changed
+// c.mu must be held
+func (c *Client) setHealthyLocked(healthy bool, err error) {
+	if c.healthy == healthy {
+		return
+	}
+	old := c.healthy
+	c.healthy = healthy
+	if healthy {
+		c.logger.Info("voltage client healthy")
+	} else if err != nil {
+		c.logger.Warn("voltage client unhealthy", errorAttrs(err)...)
+	}
+	c.notifier.publish(healthEvent{oldHealthy: old, newHealthy: healthy, err: err})
+}
+
+// publish queues a health event for the registered health callbacks
+func (n *healthNotifier) publish(event healthEvent) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.callbacks) == 0 {
+		return
+	}
+	callbacks := append([]HealthChangeFunc(nil), n.callbacks...)
+	n.enqueueLocked(func() {
+		for _, fn := range callbacks {
+			fn(event.oldHealthy, event.newHealthy, event.err)
+		}
+	})
+}
+
+// publishRotation queues a key rotation warning for the registered rotation callbacks
+func (n *healthNotifier) publishRotation(rotation KeyRotation) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.rotationCallbacks) == 0 {
+		return
+	}
+	callbacks := append([]KeyRotationFunc(nil), n.rotationCallbacks...)
+	n.enqueueLocked(func() {
+		for _, fn := range callbacks {
+			fn(rotation)
+		}
+	})
+}
+
+// enqueueLocked queues a delivery and starts the dispatcher if needed; n.mu must be held
+func (n *healthNotifier) enqueueLocked(deliver func()) {
+	n.queue = append(n.queue, deliver)
+	if !n.dispatching {
+		n.dispatching = true
+		go n.dispatch()
+	}
+}
+
+// dispatch runs queued deliveries until the queue is empty
+func (n *healthNotifier) dispatch() {
+	for {
+		n.mu.Lock()
+		if len(n.queue) == 0 {
+			n.dispatching = false
+			n.mu.Unlock()
+			return
+		}
+		deliver := n.queue[0]
+		n.queue = n.queue[1:]
+		n.mu.Unlock()
+
+		deliver()
+	}
+}
+
+// StartHealthMonitor starts the background health monitor on an initialized client
+// It replaces any monitor configured with WithHealthMonitor or started earlier
+func (c *Client) StartHealthMonitor(cfg HealthMonitorConfig) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	if !c.IsInitialized() {
+		return ErrClientNotInitialized
+	}
+
+	c.StopHealthMonitor()
+
+	c.monitorMu.Lock()
+	defer c.monitorMu.Unlock()
+	c.monitorConfig = &cfg
+	c.startHealthMonitorLocked()
+	return nil
+}
+
+// StopHealthMonitor stops the background health monitor and waits for it to exit
+// It is a no-op if no monitor is running
+func (c *Client) StopHealthMonitor() {
+	c.monitorMu.Lock()
+	monitor := c.monitor
+	c.monitor = nil
+	c.monitorMu.Unlock()
+
+	if monitor != nil {
+		close(monitor.stop)
+		<-monitor.done
+	}
+}
+
+// restartHealthMonitor replaces a running monitor with a fresh one, whose failure count
+// starts over against the restarted backend; it is a no-op if no monitor is running
+// c.mu must not be held, since an in-flight check waits for it
+func (c *Client) restartHealthMonitor() {
+	c.monitorMu.Lock()
+	running := c.monitor != nil
+	c.monitorMu.Unlock()
+
+	if running {
+		c.StopHealthMonitor()
+		c.startConfiguredHealthMonitor()
+	}
+}
+
+// startConfiguredHealthMonitor starts the monitor requested via WithHealthMonitor, if any
+func (c *Client) startConfiguredHealthMonitor() {
+	c.monitorMu.Lock()
+	defer c.monitorMu.Unlock()
+
+	if c.monitorConfig != nil && c.monitor == nil {
+		c.startHealthMonitorLocked()
+	}
+}
+
+// startHealthMonitorLocked launches the monitor goroutine; c.monitorMu must be held
+func (c *Client) startHealthMonitorLocked() {
+	monitor := &healthMonitor{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	c.monitor = monitor
+	go c.runHealthMonitor(monitor, *c.monitorConfig)
+}
+
+// runHealthMonitor checks health every interval and reinitializes after repeated failures
+func (c *Client) runHealthMonitor(monitor *healthMonitor, cfg HealthMonitorConfig) {
+	defer close(monitor.done)
+
+	// Cancel in-flight checks when the monitor is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-monitor.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-monitor.stop:
+			return
+		case <-ticker.C:
+		}
+
+		// Keys can fall due while the process runs
+		c.checkKeyRotation()
+
+		if err := c.HealthCheckContext(ctx); err == nil {
+			failures = 0
+			continue
+		}
+
+		failures++
+		if cfg.ReinitializeAfter > 0 && failures >= cfg.ReinitializeAfter {
+			// An open circuit would reject Init once the backend is terminated; wait until
+			// it is half-open, when the reinitialization becomes the probe
+			if c.breaker != nil && c.breaker.State() == CircuitOpen {
+				continue
+			}
+			// Not cancelled by stop: an interrupted reinitialize would leave the client closed
+			if err := c.ReinitializeContext(context.WithoutCancel(ctx)); err == nil {
+				failures = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package vlock
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// switchableBackend wraps MockBackend with a health switch and an Init counter
+type switchableBackend struct {
+	*MockBackend
+	down  atomic.Bool
+	inits atomic.Int32
+}
+
+var errBackendDown = NewVoltageError(int(ErrServiceUnavailable), "backend down")
+
+func newSwitchableBackend() *switchableBackend {
+	return &switchableBackend{MockBackend: NewMockBackend()}
+}
+
+func (b *switchableBackend) Init(cfg *config.Config) error {
+	b.inits.Add(1)
+	return b.MockBackend.Init(cfg)
+}
+
+func (b *switchableBackend) HealthCheck() error {
+	if b.down.Load() {
+		return errBackendDown
+	}
+	return b.MockBackend.HealthCheck()
+}
+
+type recordedHealthEvent struct {
+	old, new bool
+	err      error
+}
+
+func waitForEvent(t *testing.T, events <-chan recordedHealthEvent) recordedHealthEvent {
+	t.Helper()
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for health change")
+		return recordedHealthEvent{}
+	}
+}
+
+func TestHealthMonitorCallbacks(t *testing.T) {
+	backend := newSwitchableBackend()
+	client, err := NewClient(newBackendTestConfig(),
+		WithBackend(backend),
+		WithRetryPolicy(NoRetry),
+		WithHealthMonitor(HealthMonitorConfig{Interval: 5 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	events := make(chan recordedHealthEvent, 16)
+	client.OnHealthChange(func(old, new bool, err error) {
+		events <- recordedHealthEvent{old, new, err}
+	})
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	if e := waitForEvent(t, events); e.old || !e.new || e.err != nil {
+		t.Errorf("Expected unhealthy->healthy on initialize, got %+v", e)
+	}
+
+	// The monitor notices the outage without anyone calling HealthCheck
+	backend.down.Store(true)
+	e := waitForEvent(t, events)
+	if !e.old || e.new || !errors.Is(e.err, errBackendDown) {
+		t.Errorf("Expected healthy->unhealthy with backend error, got %+v", e)
+	}
+	if client.IsHealthy() {
+		t.Error("Client should be unhealthy")
+	}
+
+	// And the recovery
+	backend.down.Store(false)
+	if e := waitForEvent(t, events); e.old || !e.new {
+		t.Errorf("Expected unhealthy->healthy on recovery, got %+v", e)
+	}
+	lastCheck := client.LastHealthCheck()
+	time.Sleep(20 * time.Millisecond)
+	if !client.LastHealthCheck().After(lastCheck) {
+		t.Error("Monitor should keep updating LastHealthCheck")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+	if e := waitForEvent(t, events); !e.old || e.new || e.err != nil {
+		t.Errorf("Expected healthy->unhealthy on close, got %+v", e)
+	}
+}
+
+func TestHealthMonitorReinitializes(t *testing.T) {
+	backend := newSwitchableBackend()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithRetryPolicy(NoRetry))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.StartHealthMonitor(HealthMonitorConfig{Interval: 5 * time.Millisecond, ReinitializeAfter: 3}); err != nil {
+		t.Fatalf("Failed to start monitor: %v", err)
+	}
+
+	backend.down.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for backend.inits.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if backend.inits.Load() < 2 {
+		t.Fatal("Expected monitor to reinitialize the backend after repeated failures")
+	}
+
+	client.StopHealthMonitor()
+	inits := backend.inits.Load()
+	time.Sleep(50 * time.Millisecond)
+	if backend.inits.Load() != inits {
+		t.Error("Monitor should not run after StopHealthMonitor")
+	}
+}
+
+func TestHealthMonitorSkipsReinitWhileCircuitOpen(t *testing.T) {
+	backend := newSwitchableBackend()
+	client, err := NewClient(newBackendTestConfig(),
+		WithBackend(backend),
+		WithRetryPolicy(NoRetry),
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 0.3, MinRequests: 1, Window: time.Minute, CoolDown: time.Hour}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.StartHealthMonitor(HealthMonitorConfig{Interval: 5 * time.Millisecond, ReinitializeAfter: 1}); err != nil {
+		t.Fatalf("Failed to start monitor: %v", err)
+	}
+
+	// The first failed check opens the circuit; the monitor must not tear down the
+	// backend for an Init the open circuit would reject
+	backend.down.Store(true)
+	time.Sleep(100 * time.Millisecond)
+	client.StopHealthMonitor()
+
+	if got := client.Info().CircuitState; got != CircuitOpen {
+		t.Fatalf("Expected open circuit, got %v", got)
+	}
+	if got := backend.inits.Load(); got != 1 {
+		t.Errorf("Expected no reinitialization while the circuit is open, got %d inits", got)
+	}
+	if !client.IsInitialized() {
+		t.Error("Client should stay initialized while the circuit is open")
+	}
+}
+
+func TestHealthMonitorValidation(t *testing.T) {
+	if _, err := NewClient(newBackendTestConfig(), WithHealthMonitor(HealthMonitorConfig{})); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.StartHealthMonitor(HealthMonitorConfig{Interval: time.Second}); !errors.Is(err, ErrClientNotInitialized) {
+		t.Errorf("Expected ErrClientNotInitialized, got: %v", err)
+	}
+}
+
+func TestHealthCallbackCanCallClient(t *testing.T) {
+	backend := newSwitchableBackend()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithRetryPolicy(NoRetry))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	done := make(chan error, 1)
+	client.OnHealthChange(func(old, new bool, err error) {
+		if !new {
+			// Callbacks run outside the client's lock
+			done <- client.Reinitialize()
+		}
+	})
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	backend.down.Store(true)
+	client.HealthCheck()
+	backend.down.Store(false)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Reinitialize from callback failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Callback did not run")
+	}
+}
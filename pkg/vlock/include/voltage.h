@@ -0,0 +1,57 @@
+/*
+ * voltage.h - C API of the Voltage Protector library used by the vlock cgo backend
+ *
+ * All functions return 0 on success or a Voltage error code (see errors.go).
+ * Strings returned through output or error_msg parameters are allocated with
+ * malloc and must be released by the caller with free().
+ */
+#ifndef VOLTAGE_H
+#define VOLTAGE_H
+
+#include <stddef.h>
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+/* Lifecycle */
+int voltage_init(const char* config_file, char** error_msg);
+int voltage_terminate(char** error_msg);
+int voltage_health_check(char** error_msg);
+
+/* Text protection */
+int voltage_protect(const char* crypt_id, const char* input, char** output, char** error_msg);
+int voltage_access(const char* crypt_id, const char* input, char** output, char** error_msg);
+/* Decrypts with a specific (possibly retired) key version of the cryptId */
+int voltage_access_version(const char* crypt_id, const char* key_version, const char* input,
+                           char** output, char** error_msg);
+
+/*
+ * Batch text protection. inputs, outputs, codes and error_msgs hold count entries;
+ * outputs and error_msgs are provided by the caller and filled with malloc'd strings
+ * (or NULL). codes receives the result of each value. The return value reports
+ * failures of the batch as a whole, in which case no entries are filled.
+ */
+int voltage_protect_batch(const char* crypt_id, const char** inputs, size_t count,
+                          char** outputs, int* codes, char** error_msgs, char** error_msg);
+int voltage_access_batch(const char* crypt_id, const char** inputs, size_t count,
+                         char** outputs, int* codes, char** error_msgs, char** error_msg);
+
+/* Binary protection; *output is allocated with malloc and *output_len set to its length */
+int voltage_protect_bytes(const char* crypt_id, const unsigned char* input, size_t input_len,
+                          unsigned char** output, size_t* output_len, char** error_msg);
+int voltage_access_bytes(const char* crypt_id, const unsigned char* input, size_t input_len,
+                         unsigned char** output, size_t* output_len, char** error_msg);
+/* Decrypts binary data with a specific (possibly retired) key version of the cryptId */
+int voltage_access_bytes_version(const char* crypt_id, const char* key_version,
+                                 const unsigned char* input, size_t input_len,
+                                 unsigned char** output, size_t* output_len, char** error_msg);
+
+/* Library version; the returned string is owned by the library */
+const char* voltage_get_version(void);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* VOLTAGE_H */
@@ -0,0 +1,216 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// KeyVersionBackend is implemented by backends that can decrypt with retired key versions
+// declared as <previousKey> in vsconfig.xml
+type KeyVersionBackend interface {
+	// AccessVersion decrypts ciphertext with the given key version of the cryptID
+	AccessVersion(cryptID, keyVersion, ciphertext string) (string, error)
+	// AccessBytesVersion decrypts binary data with the given key version of the cryptID
+	AccessBytesVersion(cryptID, keyVersion string, data []byte) ([]byte, error)
+}
+
+// WithKeyVersionTags prefixes text protected with a cryptID that has a <keyVersion> with that
+// version, as in "{v2.1}847-29-1034". AccessText, AccessBatch and Reprotect read the tag back to
+// pick the key that protected a value after a rotation. Tagged values are longer than the
+// plaintext, so FPE cryptIDs no longer preserve length; without tags, store the key version
+// from ProtectTextVersioned alongside the value instead
+func WithKeyVersionTags() ClientOption {
+	return func(c *Client) error {
+		c.keyVersionTags = true
+		return nil
+	}
+}
+
+// ProtectTextVersioned is ProtectText that also returns the key version that protected the
+// value, empty for cryptIDs without a <keyVersion>. The ciphertext is the same as ProtectText's;
+// without WithKeyVersionTags, store the key version alongside it to read it back with
+// AccessTextVersion after a rotation
+func (c *Client) ProtectTextVersioned(ctx context.Context, cryptID, plaintext string) (ciphertext, keyVersion string, err error) {
+	ctx, start := c.startOperation(ctx, AuditProtectText)
+	ciphertext, keyVersion, err = c.protectText(ctx, cryptID, plaintext)
+	c.observe(ctx, operationEvent{start: start, op: AuditProtectText, cryptID: cryptID, count: 1, err: err})
+	return ciphertext, keyVersion, err
+}
+
+// AccessTextVersion decrypts ciphertext protected with the given key version of the cryptID,
+// which may be the current one or a <previousKey> of vsconfig.xml; an empty keyVersion
+// selects the key like AccessText
+func (c *Client) AccessTextVersion(ctx context.Context, cryptID, keyVersion, ciphertext string) (string, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessText)
+	plaintext, err := c.accessText(ctx, cryptID, keyVersion, ciphertext)
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessText, cryptID: cryptID, count: 1, err: err})
+	return plaintext, err
+}
+
+// Reprotect re-encrypts ciphertext under the current key of the cryptID
+// The key that protected ciphertext is found as for AccessText: ciphertext of a previous key
+// version is decrypted and protected again, ciphertext already on the current version, or of a
+// cryptID without key versions, is returned unchanged (tagged with WithKeyVersionTags)
+func (c *Client) Reprotect(ctx context.Context, cryptID, ciphertext string) (string, error) {
+	ctx, start := c.startOperation(ctx, AuditReprotect)
+	protected, _, err := c.reprotect(ctx, cryptID, "", ciphertext)
+	c.observe(ctx, operationEvent{start: start, op: AuditReprotect, cryptID: cryptID, count: 1, err: err})
+	return protected, err
+}
+
+// ReprotectVersion is Reprotect for ciphertext whose key version was stored alongside it,
+// as returned by ProtectTextVersioned. It also returns the current key version
+func (c *Client) ReprotectVersion(ctx context.Context, cryptID, keyVersion, ciphertext string) (string, string, error) {
+	ctx, start := c.startOperation(ctx, AuditReprotect)
+	protected, version, err := c.reprotect(ctx, cryptID, keyVersion, ciphertext)
+	c.observe(ctx, operationEvent{start: start, op: AuditReprotect, cryptID: cryptID, count: 1, err: err})
+	return protected, version, err
+}
+
+// reprotect is ReprotectVersion without auditing; an empty keyVersion is found as for AccessText
+func (c *Client) reprotect(ctx context.Context, cryptID, keyVersion, ciphertext string) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return "", "", ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := c.checkFormat(cryptID, false); err != nil {
+		return "", "", err
+	}
+
+	keyVersion, ciphertext, err = c.ciphertextKeyVersion(cryptID, keyVersion, ciphertext)
+	if err != nil {
+		return "", "", err
+	}
+
+	current := c.currentKeyVersion(cryptID)
+	if keyVersion == current {
+		return c.tagKeyVersion(current, ciphertext), current, nil
+	}
+
+	plaintext, err := c.accessLocked(ctx, cryptID, keyVersion, ciphertext)
+	if err != nil {
+		return "", "", err
+	}
+	return c.protectLocked(ctx, cryptID, plaintext)
+}
+
+// tagKeyVersion prefixes value with keyVersion when WithKeyVersionTags is used
+func (c *Client) tagKeyVersion(keyVersion, value string) string {
+	if !c.keyVersionTags || keyVersion == "" {
+		return value
+	}
+	return "{" + keyVersion + "}" + value
+}
+
+// ciphertextKeyVersion returns the key version that protected ciphertext and the ciphertext
+// without its key version tag. keyVersion, if not empty, is the version the caller stored
+// alongside the ciphertext. Otherwise it is read from the tag, which is only recognized with
+// WithKeyVersionTags and when it names a key version of the cryptID
+// Untagged ciphertext of a cryptID with <previousKey> entries is refused with ErrKeyNotFound:
+// decrypting FPE ciphertext with the wrong key does not fail but yields wrong characters
+// c.mu must be held
+func (c *Client) ciphertextKeyVersion(cryptID, keyVersion, ciphertext string) (string, string, error) {
+	var spec *config.CryptID
+	if c.security != nil {
+		spec, _ = c.security.CryptID(cryptID)
+	}
+	if spec == nil {
+		return keyVersion, ciphertext, nil
+	}
+
+	tagged, value := "", ciphertext
+	if c.keyVersionTags && strings.HasPrefix(ciphertext, "{") {
+		if end := strings.IndexByte(ciphertext, '}'); end > 1 && spec.HasKeyVersion(ciphertext[1:end]) {
+			tagged, value = ciphertext[1:end], ciphertext[end+1:]
+		}
+	}
+
+	switch {
+	case keyVersion != "" && tagged != "" && keyVersion != tagged:
+		return "", "", NewVoltageError(int(ErrInvalidParameter),
+			fmt.Sprintf("ciphertext is tagged with key version %s of cryptId %s, not %s", tagged, cryptID, keyVersion))
+	case keyVersion != "":
+		return keyVersion, value, nil
+	case tagged != "":
+		return tagged, value, nil
+	case len(spec.PreviousKeys) > 0:
+		return "", "", NewVoltageError(int(ErrKeyNotFound),
+			fmt.Sprintf("cryptId %s has previous key versions and the ciphertext does not name its key version; "+
+				"use AccessTextVersion or WithKeyVersionTags", cryptID))
+	}
+	return spec.KeyVersion, ciphertext, nil
+}
+
+// currentKeyVersion returns the <keyVersion> of cryptID, or "" if it has none
+// c.mu must be held
+func (c *Client) currentKeyVersion(cryptID string) string {
+	if c.security == nil {
+		return ""
+	}
+	if spec, ok := c.security.CryptID(cryptID); ok {
+		return spec.KeyVersion
+	}
+	return ""
+}
+
+// previousKeyBackend returns the backend to decrypt with keyVersion of cryptID, or nil if
+// keyVersion is empty or current and the plain Access methods apply
+// c.mu must be held
+func (c *Client) previousKeyBackend(cryptID, keyVersion string) (KeyVersionBackend, error) {
+	if keyVersion == "" || keyVersion == c.currentKeyVersion(cryptID) {
+		return nil, nil
+	}
+
+	var spec *config.CryptID
+	if c.security != nil {
+		spec, _ = c.security.CryptID(cryptID)
+	}
+	if spec == nil || !spec.HasKeyVersion(keyVersion) {
+		return nil, NewVoltageError(int(ErrKeyNotFound), fmt.Sprintf("cryptId %s has no key version %s", cryptID, keyVersion))
+	}
+
+	versioned, ok := c.backend.(KeyVersionBackend)
+	if !ok {
+		return nil, NewVoltageError(int(ErrKeyNotFound),
+			fmt.Sprintf("backend %s cannot decrypt with previous key version %s", c.backend.Version(), keyVersion))
+	}
+	return versioned, nil
+}
+
+// accessLocked decrypts ciphertext with the given key version, or the current key if it is empty
+// c.mu must be held
+func (c *Client) accessLocked(ctx context.Context, cryptID, keyVersion, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", NewVoltageError(int(ErrInvalidData), "ciphertext cannot be empty")
+	}
+
+	versioned, err := c.previousKeyBackend(cryptID, keyVersion)
+	if err != nil {
+		return "", err
+	}
+	if versioned != nil {
+		return invoke(ctx, c, "access", func() (string, error) {
+			return versioned.AccessVersion(cryptID, keyVersion, ciphertext)
+		}, nil)
+	}
+
+	backend := c.backend
+	return invoke(ctx, c, "access", func() (string, error) {
+		return backend.Access(cryptID, ciphertext)
+	}, nil)
+}
@@ -0,0 +1,299 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRotationConfig writes a vsconfig.xml whose SSN, TEXT and BINARY cryptIDs use key version current,
+// keeping the given previous versions; keys are derived from the version names
+func writeRotationConfig(t *testing.T, path, current string, previous ...string) {
+	t.Helper()
+	var history string
+	for _, version := range previous {
+		history += fmt.Sprintf(`<previousKey version="%s" key="key-%s"/>`, version, version)
+	}
+	xml := fmt.Sprintf(`<VoltageSecurityConfiguration>
+  <cryptId name="SSN_Internal" algorithm="FPE" key="key-%[1]s" format="NUMERIC">
+    <keyVersion>%[1]s</keyVersion>%[2]s
+  </cryptId>
+  <cryptId name="TEXT_Internal" algorithm="AES256" key="key-%[1]s" format="BASE64">
+    <keyVersion>%[1]s</keyVersion>%[2]s
+  </cryptId>
+  <cryptId name="BINARY_Internal" algorithm="AES256" key="key-%[1]s" format="BINARY">
+    <keyVersion>%[1]s</keyVersion>%[2]s
+  </cryptId>
+</VoltageSecurityConfiguration>`, current, history)
+	if err := os.WriteFile(path, []byte(xml), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestProtectTextPreservesLengthWithProdConfig(t *testing.T) {
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = "../config/prod/vsconfig.xml"
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for cryptID, in := range map[string]string{
+		"SSN_Internal":   "123-45-6789",
+		"CCN_Internal":   "4111-1111-1111-1111",
+		"EMAIL_Internal": "jane.doe@example.com",
+	} {
+		out, err := client.ProtectText(ctx, cryptID, in)
+		if err != nil {
+			t.Fatalf("%s: ProtectText failed: %v", cryptID, err)
+		}
+		if len(out) != len(in) {
+			t.Errorf("%s: ProtectText changed the length: %q -> %q", cryptID, in, out)
+		}
+
+		versioned, version, err := client.ProtectTextVersioned(ctx, cryptID, in)
+		if err != nil || version != "v2.1" || len(versioned) != len(in) {
+			t.Errorf("%s: ProtectTextVersioned = %q, %q, %v", cryptID, versioned, version, err)
+		}
+	}
+}
+
+// newRotationClient returns an initialized mock client using the vsconfig.xml at xmlPath
+func newRotationClient(t *testing.T, xmlPath string, opts ...ClientOption) *Client {
+	t.Helper()
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = xmlPath
+	client, err := NewClient(cfg, append([]ClientOption{WithBackend(NewMockBackend())}, opts...)...)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, xmlPath, "v1")
+	client := newRotationClient(t, xmlPath)
+
+	oldSSN, version, err := client.ProtectTextVersioned(ctx, "SSN_Internal", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectTextVersioned failed: %v", err)
+	}
+	if version != "v1" {
+		t.Fatalf("Expected key version v1, got %q", version)
+	}
+	oldText, err := client.ProtectText(ctx, "TEXT_Internal", "hello")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+
+	// Rotate to v2, keeping v1 for decryption
+	writeRotationConfig(t, xmlPath, "v2", "v1")
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+
+	var voltageErr *VoltageError
+	for cryptID, old := range map[string]string{"SSN_Internal": oldSSN, "TEXT_Internal": oldText} {
+		want := map[string]string{"SSN_Internal": "123-45-6789", "TEXT_Internal": "hello"}[cryptID]
+		if got, err := client.AccessTextVersion(ctx, cryptID, "v1", old); err != nil || got != want {
+			t.Errorf("%s: expected %s from v1 ciphertext, got %s (%v)", cryptID, want, got, err)
+		}
+
+		// Untagged ciphertext does not name its key, so guessing is refused
+		if got, err := client.AccessText(ctx, cryptID, old); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+			t.Errorf("%s: expected ErrKeyNotFound from AccessText of untagged ciphertext, got %q (%v)", cryptID, got, err)
+		}
+		if _, err := client.Reprotect(ctx, cryptID, old); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+			t.Errorf("%s: expected ErrKeyNotFound from Reprotect of untagged ciphertext, got: %v", cryptID, err)
+		}
+
+		upgraded, version, err := client.ReprotectVersion(ctx, cryptID, "v1", old)
+		if err != nil {
+			t.Fatalf("%s: ReprotectVersion failed: %v", cryptID, err)
+		}
+		if version != "v2" || len(upgraded) != len(old) {
+			t.Errorf("%s: expected a v2 value of the same length after ReprotectVersion, got %q (%s)", cryptID, upgraded, version)
+		}
+		if got, err := client.AccessTextVersion(ctx, cryptID, "v2", upgraded); err != nil || got != want {
+			t.Errorf("%s: expected %s from reprotected value, got %s (%v)", cryptID, want, got, err)
+		}
+
+		again, version, err := client.ReprotectVersion(ctx, cryptID, "v2", upgraded)
+		if err != nil || again != upgraded || version != "v2" {
+			t.Errorf("%s: ReprotectVersion of current ciphertext should be a no-op, got %s (%v)", cryptID, again, err)
+		}
+	}
+
+	results, err := client.ProtectBatch(ctx, "SSN_Internal", []string{"123-45-6789"})
+	if err != nil || results[0].KeyVersion != "v2" || len(results[0].Value) != len("123-45-6789") {
+		t.Errorf("Unexpected ProtectBatch results %+v (%v)", results, err)
+	}
+	results, err = client.AccessBatch(ctx, "SSN_Internal", []string{oldSSN})
+	if err != nil || !errors.As(results[0].Err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound from AccessBatch of untagged ciphertext, got %+v (%v)", results, err)
+	}
+
+	if _, err := client.AccessTextVersion(ctx, "SSN_Internal", "v0", oldSSN); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for unknown key version, got: %v", err)
+	}
+
+	// Once v1 is dropped its ciphertext can no longer be read
+	writeRotationConfig(t, xmlPath, "v2")
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+	if _, err := client.AccessTextVersion(ctx, "SSN_Internal", "v1", oldSSN); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound for dropped key version, got: %v", err)
+	}
+}
+
+func TestKeyVersionTags(t *testing.T) {
+	ctx := context.Background()
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, xmlPath, "v1")
+	client := newRotationClient(t, xmlPath, WithKeyVersionTags())
+
+	oldSSN, err := client.ProtectText(ctx, "SSN_Internal", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if !strings.HasPrefix(oldSSN, "{v1}") || len(oldSSN) != len("{v1}123-45-6789") {
+		t.Fatalf("Expected a value tagged with v1, got %q", oldSSN)
+	}
+	oldText, err := client.ProtectText(ctx, "TEXT_Internal", "hello")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+
+	writeRotationConfig(t, xmlPath, "v2", "v1")
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+
+	for cryptID, old := range map[string]string{"SSN_Internal": oldSSN, "TEXT_Internal": oldText} {
+		want := map[string]string{"SSN_Internal": "123-45-6789", "TEXT_Internal": "hello"}[cryptID]
+		if got, err := client.AccessText(ctx, cryptID, old); err != nil || got != want {
+			t.Errorf("%s: expected %s from v1 ciphertext, got %s (%v)", cryptID, want, got, err)
+		}
+
+		upgraded, err := client.Reprotect(ctx, cryptID, old)
+		if err != nil {
+			t.Fatalf("%s: Reprotect failed: %v", cryptID, err)
+		}
+		if !strings.HasPrefix(upgraded, "{v2}") {
+			t.Errorf("%s: expected a value tagged with v2 after Reprotect, got %q", cryptID, upgraded)
+		}
+		if got, err := client.AccessText(ctx, cryptID, upgraded); err != nil || got != want {
+			t.Errorf("%s: expected %s from reprotected value, got %s (%v)", cryptID, want, got, err)
+		}
+		if again, err := client.Reprotect(ctx, cryptID, upgraded); err != nil || again != upgraded {
+			t.Errorf("%s: Reprotect of current ciphertext should be a no-op, got %s (%v)", cryptID, again, err)
+		}
+	}
+
+	current, err := client.ProtectBatch(ctx, "SSN_Internal", []string{"987-65-4321"})
+	if err != nil || !strings.HasPrefix(current[0].Value, "{v2}") || current[0].KeyVersion != "v2" {
+		t.Fatalf("Unexpected ProtectBatch results %+v (%v)", current, err)
+	}
+	results, err := client.AccessBatch(ctx, "SSN_Internal", []string{oldSSN, current[0].Value, "{v2}"})
+	if err != nil || results[0].Value != "123-45-6789" || results[1].Value != "987-65-4321" || results[2].Err == nil {
+		t.Errorf("Unexpected AccessBatch results %+v (%v)", results, err)
+	}
+
+	// Only tags naming a key version of the cryptID are read, and the tag must match a given version
+	var voltageErr *VoltageError
+	untagged := strings.TrimPrefix(oldSSN, "{v1}")
+	for _, value := range []string{untagged, "{v9}" + untagged} {
+		if _, err := client.AccessText(ctx, "SSN_Internal", value); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+			t.Errorf("%q: expected ErrKeyNotFound, got: %v", value, err)
+		}
+	}
+	if got, err := client.AccessTextVersion(ctx, "SSN_Internal", "v1", untagged); err != nil || got != "123-45-6789" {
+		t.Errorf("Expected 123-45-6789 from AccessTextVersion, got %s (%v)", got, err)
+	}
+	if _, err := client.AccessTextVersion(ctx, "SSN_Internal", "v2", oldSSN); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidParameter {
+		t.Errorf("Expected ErrInvalidParameter for a conflicting tag, got: %v", err)
+	}
+}
+
+func TestKeyRotationBinary(t *testing.T) {
+	ctx := context.Background()
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, xmlPath, "v1")
+
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = xmlPath
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()), WithStreamChunkSize(16))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	data := bytes.Repeat([]byte("rotate me "), 10)
+	oldBytes, version, err := client.ProtectBytesVersioned(ctx, "BINARY_Internal", data)
+	if err != nil || version != "v1" {
+		t.Fatalf("ProtectBytesVersioned = %q, %v", version, err)
+	}
+	var oldStream bytes.Buffer
+	if err := client.ProtectStream(ctx, "BINARY_Internal", &oldStream, bytes.NewReader(data)); err != nil {
+		t.Fatalf("ProtectStream failed: %v", err)
+	}
+
+	writeRotationConfig(t, xmlPath, "v2", "v1")
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+
+	if _, err := client.AccessBytes(ctx, "BINARY_Internal", oldBytes); err == nil {
+		t.Error("The current key should not decrypt v1 data")
+	}
+	if got, err := client.AccessBytesVersion(ctx, "BINARY_Internal", "v1", oldBytes); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("AccessBytesVersion of v1 data = %q, %v", got, err)
+	}
+
+	// The stream header names v1, so the old stream stays readable
+	var out bytes.Buffer
+	if err := client.AccessStream(ctx, "", &out, &oldStream); err != nil || !bytes.Equal(out.Bytes(), data) {
+		t.Errorf("AccessStream of a v1 stream = %q, %v", out.Bytes(), err)
+	}
+}
+
+func TestPreviousKeyVersionNeedsBackendSupport(t *testing.T) {
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, xmlPath, "v2", "v1")
+
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = xmlPath
+	client, err := NewClient(cfg, WithBackend(&recordingBackend{}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	var voltageErr *VoltageError
+	if _, err := client.AccessTextVersion(context.Background(), "SSN_Internal", "v1", "123"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package vlock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// LevelTrace is the slog level used for per-operation logs at LogLevel 4
+const LevelTrace = slog.LevelDebug - 4
+
+// logFileCheckInterval is how often a LogFile writer checks whether its file was rotated away
+const logFileCheckInterval = time.Second
+
+// WithLogger makes the client log to logger instead of the logger built from
+// Config.LogLevel and Config.LogFile. Without either the client does not log
+//
+// The client logs lifecycle transitions, health changes, retries and failures with
+// operation names, cryptIDs and error codes; it never logs plaintext, ciphertext or secrets
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) error {
+		if logger == nil {
+			return fmt.Errorf("logger cannot be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// LogLevel converts a Config.LogLevel value to a slog level
+// 0 = error, 1 = warn, 2 = info, 3 = debug, 4 = trace; ok is false for negative values, which disable logging
+func LogLevel(level int) (slog.Level, bool) {
+	switch {
+	case level < 0:
+		return 0, false
+	case level == 0:
+		return slog.LevelError, true
+	case level == 1:
+		return slog.LevelWarn, true
+	case level == 2:
+		return slog.LevelInfo, true
+	case level == 3:
+		return slog.LevelDebug, true
+	default:
+		return LevelTrace, true
+	}
+}
+
+// newConfigLogger builds the default logger: text records at Config.LogLevel, written to
+// Config.LogFile or, if no file is configured, to standard error
+// Unless a LogFile is given or Config.LogLevelSet reports an explicit LogLevel,
+// records are discarded, so a library client stays silent by default
+func newConfigLogger(cfg *config.Config) (*slog.Logger, *logFile) {
+	level, ok := LogLevel(cfg.LogLevel)
+	if !ok || !loggingConfigured(cfg) {
+		return slog.New(slog.DiscardHandler), nil
+	}
+
+	var out io.Writer = os.Stderr
+	var file *logFile
+	if cfg.LogFile != "" {
+		file = newLogFile(cfg.LogFile)
+		out = file
+	}
+
+	handler := slog.NewTextHandler(out, &slog.HandlerOptions{Level: level})
+	return slog.New(handler).With("app", cfg.AppName, "env", cfg.AppEnv), file
+}
+
+// loggingConfigured reports whether cfg asks for logging rather than holding the default LogLevel
+func loggingConfigured(cfg *config.Config) bool {
+	return cfg.LogFile != "" || cfg.LogLevelSet()
+}
+
+// logFile is an append-only log writer that survives external rotation
+// If the file is renamed or removed (as logrotate does), the next write after
+// logFileCheckInterval reopens the path, so no reload signal is needed
+type logFile struct {
+	path string
+
+	mu        sync.Mutex
+	file      *os.File
+	lastCheck time.Time
+}
+
+func newLogFile(path string) *logFile {
+	return &logFile{path: path}
+}
+
+// Write appends p to the log file, reopening it if it was rotated
+// Failures to open the file are reported to standard error and the record is dropped,
+// so logging never fails a Voltage operation
+func (l *logFile) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil && time.Since(l.lastCheck) >= logFileCheckInterval {
+		l.lastCheck = time.Now()
+		if l.rotatedLocked() {
+			l.file.Close()
+			l.file = nil
+		}
+	}
+
+	if l.file == nil {
+		f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "vlock: cannot open log file: %v\n", err)
+			return len(p), nil
+		}
+		l.file = f
+		l.lastCheck = time.Now()
+	}
+
+	return l.file.Write(p)
+}
+
+// rotatedLocked reports whether the open file is no longer the one at l.path
+func (l *logFile) rotatedLocked() bool {
+	current, err := os.Stat(l.path)
+	if err != nil {
+		return true
+	}
+	open, err := l.file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(current, open)
+}
+
+// Close closes the file; a later write reopens it
+func (l *logFile) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// logLifecycle logs the outcome of a lifecycle transition such as initialize or close
+func (c *Client) logLifecycle(event string, err error) {
+	if err != nil {
+		c.logger.Error("voltage client lifecycle failed", append([]any{"event", event}, errorAttrs(err)...)...)
+		return
+	}
+	c.logger.Info("voltage client lifecycle", "event", event, "backend", c.backend.Version())
+}
+
+// errorAttrs describes err for logs: the message and, for Voltage errors, the error code
+func errorAttrs(err error) []any {
+	attrs := []any{slog.String("error", err.Error())}
+	var voltageErr *VoltageError
+	if errors.As(err, &voltageErr) {
+		attrs = append(attrs, slog.Int("code", int(voltageErr.Code)))
+	}
+	return attrs
+}
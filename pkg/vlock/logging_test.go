@@ -0,0 +1,207 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent log writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newCapturingLogger() (*slog.Logger, *syncBuffer) {
+	buf := &syncBuffer{}
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: LevelTrace})), buf
+}
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		level int
+		want  slog.Level
+		ok    bool
+	}{
+		{-1, 0, false},
+		{0, slog.LevelError, true},
+		{1, slog.LevelWarn, true},
+		{2, slog.LevelInfo, true},
+		{3, slog.LevelDebug, true},
+		{4, LevelTrace, true},
+	}
+	for _, tt := range tests {
+		got, ok := LogLevel(tt.level)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("LogLevel(%d) = %v, %v; want %v, %v", tt.level, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	if _, err := NewClient(newBackendTestConfig(), WithLogger(nil)); err == nil {
+		t.Error("Expected error for nil logger")
+	}
+}
+
+func TestClientLogsWithoutSecrets(t *testing.T) {
+	logger, buf := newCapturingLogger()
+	cfg := newBackendTestConfig()
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	ctx := context.Background()
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.AccessText(ctx, "", protected); err != nil {
+		t.Fatalf("AccessText failed: %v", err)
+	}
+	client.ProtectText(ctx, "", "1")
+	if err := client.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"event=initialize", "event=close", "voltage client healthy", "op=protect", "op=access"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected log to contain %q:\n%s", want, out)
+		}
+	}
+	for _, secret := range []string{"123-45-6789", protected, cfg.DEKSharedSecret} {
+		if strings.Contains(out, secret) {
+			t.Errorf("Log contains sensitive value %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestRetriesAreLogged(t *testing.T) {
+	logger, buf := newCapturingLogger()
+	backend := &flakyBackend{failures: 1, failWith: NewVoltageError(int(ErrServiceUnavailable), "busy")}
+	backend.MockBackend = NewMockBackend()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithLogger(logger),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, `level=WARN msg="retrying voltage operation" op=protect attempt=1`) || !strings.Contains(out, "code=19") {
+		t.Errorf("Expected retry warning in log:\n%s", out)
+	}
+}
+
+func TestLogFileFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vlock.log")
+	cfg := newBackendTestConfig()
+	cfg.LogLevel = 2
+	cfg.LogFile = path
+
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	// Simulate logrotate moving the file away
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	client.logFile.mu.Lock()
+	client.logFile.lastCheck = time.Time{}
+	client.logFile.mu.Unlock()
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Failed to close: %v", err)
+	}
+
+	rotated, _ := os.ReadFile(path + ".1")
+	current, _ := os.ReadFile(path)
+	if !strings.Contains(string(rotated), "event=initialize") || strings.Contains(string(rotated), "event=close") {
+		t.Errorf("Unexpected rotated log:\n%s", rotated)
+	}
+	if !strings.Contains(string(current), "event=close") {
+		t.Errorf("Expected close to be logged to the new file, got:\n%s", current)
+	}
+}
+
+func TestDefaultLoggerIsSilent(t *testing.T) {
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("A client without LogLevel or LogFile configured should not log")
+	}
+
+	// A LogLevel set in code is honored unless it is the default the Config started with
+	defaults := config.NewConfig()
+	defaults.AppName, defaults.AppVersion, defaults.AppEnv, defaults.DEKSharedSecret = "TestApp", "1.0.0", "DEV", "secret"
+	client, err = NewClient(defaults, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("A client with NewConfig's LogLevel should not log")
+	}
+	for _, cfg := range []*config.Config{newBackendTestConfig(), defaults} {
+		cfg.LogLevel = 3
+		client, err = NewClient(cfg, WithBackend(NewMockBackend()))
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		if !client.logger.Enabled(context.Background(), slog.LevelDebug) {
+			t.Error("LogLevel 3 set in code should enable debug logging")
+		}
+	}
+
+	// LogLevel from the .cfg file or FP_LOGLEVEL enables logging to standard error
+	t.Setenv("FP_LOGLEVEL", "1")
+	t.Setenv("FP_APPNAME", "TestApp")
+	t.Setenv("FP_APPVERSION", "1.0.0")
+	t.Setenv("FP_APPENV", "DEV")
+	t.Setenv("FP_DEFAULT_SHAREDSECRET", "secret")
+	cfg, err := config.LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	client, err = NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if !client.logger.Enabled(context.Background(), slog.LevelWarn) || client.logger.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("FP_LOGLEVEL=1 should enable warnings only")
+	}
+}
@@ -0,0 +1,208 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Mask pattern characters
+const (
+	MaskHide   = 'X' // Replaces the value character with 'X'
+	MaskReveal = '#' // Keeps the value character
+)
+
+// ErrMaskNotFound is returned by AccessMasked when vsconfig.xml has no <mask> for the cryptID
+var ErrMaskNotFound = &VoltageError{
+	Code:    ErrInvalidParameter,
+	Message: "no mask registered for cryptId",
+	Detail:  "add a <mask> element for the cryptId to vsconfig.xml",
+}
+
+// maskRun is a maximal run of placeholder or literal pattern characters
+type maskRun struct {
+	text    string
+	literal bool
+}
+
+// Mask applies a masking pattern such as "XXX-XX-####" to clear text
+// X hides a character, # reveals it and every other pattern character is a literal
+//
+// When value contains the pattern's literals ("123-45-6789", "john@example.com" for
+// "XXX@XXXXX.XXX") each part of value is masked by the matching part of the pattern
+// A part made only of X may differ in length from its placeholders, so variable-length
+// fields such as email names are hidden entirely; other parts must match in length
+//
+// Otherwise the letters and digits of value are laid out over the placeholders in order
+// ("123456789" becomes "XXX-XX-6789"), and their number must equal the number of placeholders
+func Mask(pattern, value string) (string, error) {
+	if pattern == "" {
+		return "", NewVoltageError(int(ErrInvalidParameter), "mask pattern cannot be empty")
+	}
+	if value == "" {
+		return "", NewVoltageError(int(ErrInvalidData), "value cannot be empty")
+	}
+
+	runs := parseMaskPattern(pattern)
+	if masked, ok := maskBySegments(runs, value); ok {
+		return masked, nil
+	}
+	return maskByPosition(pattern, value)
+}
+
+// AccessMasked decrypts ciphertext and masks it with the pattern registered for the cryptID
+// If cryptID is empty, Config.DefaultCryptID is used
+// ErrMaskNotFound is returned, without decrypting, if vsconfig.xml has no mask for the cryptID
+func (c *Client) AccessMasked(ctx context.Context, cryptID, ciphertext string) (string, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessMasked)
+	masked, err := c.accessMasked(ctx, cryptID, ciphertext)
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessMasked, cryptID: cryptID, count: 1, err: err})
+	return masked, err
+}
+
+// accessMasked is AccessMasked without auditing
+func (c *Client) accessMasked(ctx context.Context, cryptID, ciphertext string) (string, error) {
+	pattern, cryptID, err := c.maskPattern(cryptID)
+	if err != nil {
+		return "", err
+	}
+
+	clear, err := c.accessText(ctx, cryptID, "", ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return Mask(pattern, clear)
+}
+
+// maskPattern resolves cryptID and returns it with its registered mask pattern
+func (c *Client) maskPattern(cryptID string) (string, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return "", "", ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if c.security == nil {
+		return "", "", ErrMaskNotFound
+	}
+	mask, ok := c.security.MaskFor(cryptID)
+	if !ok {
+		return "", "", ErrMaskNotFound
+	}
+	return mask.Pattern, cryptID, nil
+}
+
+// parseMaskPattern splits a pattern into placeholder and literal runs
+func parseMaskPattern(pattern string) []maskRun {
+	var runs []maskRun
+	for _, r := range pattern {
+		literal := r != MaskHide && r != MaskReveal
+		if n := len(runs); n > 0 && runs[n-1].literal == literal {
+			runs[n-1].text += string(r)
+			continue
+		}
+		runs = append(runs, maskRun{text: string(r), literal: literal})
+	}
+	return runs
+}
+
+// maskBySegments masks value part by part between the pattern's literals
+// It reports false if value does not have the pattern's structure
+func maskBySegments(runs []maskRun, value string) (string, bool) {
+	var out strings.Builder
+	rest := value
+
+	for i, run := range runs {
+		if run.literal {
+			if !strings.HasPrefix(rest, run.text) {
+				return "", false
+			}
+			out.WriteString(run.text)
+			rest = rest[len(run.text):]
+			continue
+		}
+
+		// The part ends where the next literal starts, or at the end of value
+		part := rest
+		if i+1 < len(runs) {
+			end := strings.Index(rest, runs[i+1].text)
+			if end < 0 {
+				return "", false
+			}
+			part = rest[:end]
+		}
+		masked, ok := maskPart(run.text, part)
+		if !ok {
+			return "", false
+		}
+		out.WriteString(masked)
+		rest = rest[len(part):]
+	}
+
+	if rest != "" {
+		return "", false
+	}
+	return out.String(), true
+}
+
+// maskPart masks part with the placeholders of a single run
+func maskPart(placeholders, part string) (string, bool) {
+	chars := []rune(part)
+	if len(chars) == 0 {
+		return "", false
+	}
+
+	switch {
+	case len(chars) == len(placeholders):
+		for i, p := range placeholders {
+			if p == MaskHide {
+				chars[i] = MaskHide
+			}
+		}
+		return string(chars), true
+	case strings.Trim(placeholders, string(MaskHide)) == "":
+		return strings.Repeat(string(MaskHide), len(chars)), true
+	default:
+		return "", false
+	}
+}
+
+// maskByPosition lays the letters and digits of value over the pattern's placeholders
+func maskByPosition(pattern, value string) (string, error) {
+	var data []rune
+	for _, r := range value {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			data = append(data, r)
+		}
+	}
+
+	placeholders := strings.Count(pattern, string(MaskHide)) + strings.Count(pattern, string(MaskReveal))
+	if len(data) != placeholders {
+		return "", NewVoltageError(int(ErrInvalidData),
+			fmt.Sprintf("value has %d characters to mask but pattern %q has %d placeholders", len(data), pattern, placeholders))
+	}
+
+	var out strings.Builder
+	next := 0
+	for _, p := range pattern {
+		switch p {
+		case MaskHide:
+			out.WriteRune(MaskHide)
+			next++
+		case MaskReveal:
+			out.WriteRune(data[next])
+			next++
+		default:
+			out.WriteRune(p)
+		}
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,82 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    string
+	}{
+		{"XXX-XX-####", "123-45-6789", "XXX-XX-6789"},
+		{"XXX-XX-####", "123456789", "XXX-XX-6789"},
+		{"XXXX-XXXX-XXXX-####", "4111-1111-1111-1234", "XXXX-XXXX-XXXX-1234"},
+		{"XXXX-XXXX-XXXX-####", "4111111111111234", "XXXX-XXXX-XXXX-1234"},
+		{"XXX@XXXXX.XXX", "john@example.com", "XXXX@XXXXXXX.XXX"},
+		{"XXX@XXXXX.XXX", "abc@defgh.ijk", "XXX@XXXXX.XXX"},
+		{"#XX@XXXXX.###", "abc@defgh.ijk", "aXX@XXXXX.ijk"},
+		{"##-##", "ab-cd", "ab-cd"},
+	}
+
+	for _, tt := range tests {
+		got, err := Mask(tt.pattern, tt.value)
+		if err != nil {
+			t.Errorf("Mask(%q, %q) failed: %v", tt.pattern, tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Mask(%q, %q) = %q, want %q", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaskErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		code    ErrorCode
+	}{
+		{"", "123", ErrInvalidParameter},
+		{"XXX-XX-####", "", ErrInvalidData},
+		{"XXX-XX-####", "12345678", ErrInvalidData},
+		{"XXX-XX-####", "123-45-67890", ErrInvalidData},
+		{"X#X#", "12345", ErrInvalidData},
+	}
+
+	for _, tt := range tests {
+		_, err := Mask(tt.pattern, tt.value)
+		var voltageErr *VoltageError
+		if !errors.As(err, &voltageErr) || voltageErr.Code != tt.code {
+			t.Errorf("Mask(%q, %q): expected code %d, got: %v", tt.pattern, tt.value, tt.code, err)
+		}
+	}
+}
+
+func TestAccessMasked(t *testing.T) {
+	client := newBytesTestClient(t)
+	ctx := context.Background()
+
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	masked, err := client.AccessMasked(ctx, "", protected)
+	if err != nil {
+		t.Fatalf("AccessMasked failed: %v", err)
+	}
+	if masked != "XXX-XX-6789" {
+		t.Errorf("Expected XXX-XX-6789, got %s", masked)
+	}
+
+	protected, err = client.ProtectText(ctx, "TEXT_Internal", "free text")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.AccessMasked(ctx, "TEXT_Internal", protected); !errors.Is(err, ErrMaskNotFound) {
+		t.Errorf("Expected ErrMaskNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package vlock
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricsContentType is the Content-Type of the Prometheus text exposition format
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsOtherCryptID labels operations on cryptIDs neither vsconfig.xml nor DefaultCryptId
+// defines, so callers passing arbitrary names cannot grow the metric set without bound
+const metricsOtherCryptID = "other"
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the operation latency histogram
+var DefaultLatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics collects counters and histograms for one client
+// It renders them in the Prometheus text format and serves them as an http.Handler,
+// so no metrics library or server is needed
+type Metrics struct {
+	client *Client
+
+	mu                  sync.Mutex
+	operations          map[operationKey]uint64
+	latency             map[string]*histogram // By operation
+	retries             map[string]uint64     // By backend call
+	healthCheckFailures uint64
+}
+
+type operationKey struct {
+	op, cryptID, result string
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket of DefaultLatencyBuckets, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func newMetrics(c *Client) *Metrics {
+	return &Metrics{
+		client:     c,
+		operations: make(map[operationKey]uint64),
+		latency:    make(map[string]*histogram),
+		retries:    make(map[string]uint64),
+	}
+}
+
+// Metrics returns the client's metrics collector
+func (c *Client) Metrics() *Metrics {
+	return c.metrics
+}
+
+// observe records a finished public operation in its span, the metrics and the audit trail
+func (c *Client) observe(ctx context.Context, event operationEvent) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if event.cryptID == "" {
+		event.cryptID = c.config.DefaultCryptID
+	}
+	endSpan(ctx, event)
+	c.metrics.observeOperation(event, c.metricsCryptIDLocked(event.cryptID))
+	c.auditLocked(ctx, event)
+}
+
+// metricsCryptIDLocked returns the cryptID label for cryptID
+func (c *Client) metricsCryptIDLocked(cryptID string) string {
+	if cryptID != "" && cryptID == c.config.DefaultCryptID {
+		return cryptID
+	}
+	if c.security != nil {
+		if _, ok := c.security.CryptID(cryptID); ok {
+			return cryptID
+		}
+	}
+	return metricsOtherCryptID
+}
+
+func (m *Metrics) observeOperation(event operationEvent, cryptID string) {
+	result := "success"
+	if event.err != nil {
+		result = "failure"
+	}
+	seconds := time.Since(event.start).Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.operations[operationKey{op: event.op, cryptID: cryptID, result: result}]++
+
+	h := m.latency[event.op]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(DefaultLatencyBuckets))}
+		m.latency[event.op] = h
+	}
+	for i, bound := range DefaultLatencyBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func (m *Metrics) observeRetry(op string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[op]++
+}
+
+func (m *Metrics) observeHealthCheckFailure() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthCheckFailures++
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	c := m.client
+	c.mu.RLock()
+	initialized, healthy := c.initialized, c.healthy
+	c.mu.RUnlock()
+	state := CircuitClosed
+	if c.breaker != nil {
+		state = c.breaker.State()
+	}
+
+	bw := bufio.NewWriter(w)
+
+	m.mu.Lock()
+	writeHeader(bw, "vlock_operations_total", "counter", "Client operations by operation, cryptID and result")
+	opKeys := make([]operationKey, 0, len(m.operations))
+	for key := range m.operations {
+		opKeys = append(opKeys, key)
+	}
+	sort.Slice(opKeys, func(i, j int) bool {
+		a, b := opKeys[i], opKeys[j]
+		if a.op != b.op {
+			return a.op < b.op
+		}
+		if a.cryptID != b.cryptID {
+			return a.cryptID < b.cryptID
+		}
+		return a.result < b.result
+	})
+	for _, key := range opKeys {
+		fmt.Fprintf(bw, "vlock_operations_total{operation=%s,crypt_id=%s,result=%s} %d\n",
+			quoteLabel(key.op), quoteLabel(key.cryptID), quoteLabel(key.result), m.operations[key])
+	}
+
+	writeHeader(bw, "vlock_operation_duration_seconds", "histogram", "Client operation latency in seconds, including retries")
+	for _, op := range sortedKeys(m.latency) {
+		h := m.latency[op]
+		label := "operation=" + quoteLabel(op)
+		var cumulative uint64
+		for i, bound := range DefaultLatencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(bw, "vlock_operation_duration_seconds_bucket{%s,le=%s} %d\n", label, quoteLabel(formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(bw, "vlock_operation_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(bw, "vlock_operation_duration_seconds_sum{%s} %s\n", label, formatFloat(h.sum))
+		fmt.Fprintf(bw, "vlock_operation_duration_seconds_count{%s} %d\n", label, h.count)
+	}
+
+	writeHeader(bw, "vlock_retries_total", "counter", "Retried backend calls by call")
+	for _, op := range sortedKeys(m.retries) {
+		fmt.Fprintf(bw, "vlock_retries_total{call=%s} %d\n", quoteLabel(op), m.retries[op])
+	}
+
+	writeHeader(bw, "vlock_health_check_failures_total", "counter", "Failed backend health checks")
+	fmt.Fprintf(bw, "vlock_health_check_failures_total %d\n", m.healthCheckFailures)
+	m.mu.Unlock()
+
+	writeHeader(bw, "vlock_circuit_state", "gauge", "Circuit breaker state: 0 closed, 1 open, 2 half-open")
+	fmt.Fprintf(bw, "vlock_circuit_state %d\n", int(state))
+
+	writeHeader(bw, "vlock_initialized", "gauge", "1 if the client is initialized")
+	fmt.Fprintf(bw, "vlock_initialized %d\n", boolGauge(initialized))
+
+	writeHeader(bw, "vlock_healthy", "gauge", "1 if the last health check succeeded")
+	fmt.Fprintf(bw, "vlock_healthy %d\n", boolGauge(healthy))
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics for a Prometheus scrape, typically at /metrics
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", MetricsContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := m.WritePrometheus(w); err != nil {
+		m.client.mu.RLock()
+		m.client.logger.Debug("metrics scrape not written", "error", err.Error())
+		m.client.mu.RUnlock()
+	}
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// quoteLabel quotes a label value with the escapes of the exposition format
+func quoteLabel(value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return `"` + value + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func boolGauge(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,154 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsCountOperations(t *testing.T) {
+	backend := &flakyBackend{failures: 1, failWith: NewVoltageError(int(ErrServiceUnavailable), "busy")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	ctx := context.Background()
+
+	protected, err := client.ProtectText(ctx, "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if _, err := client.AccessText(ctx, "SSN_Internal", protected); err != nil {
+		t.Fatalf("AccessText failed: %v", err)
+	}
+	if _, err := client.AccessText(ctx, "Made_Up_ID", protected); err == nil {
+		t.Fatal("Expected AccessText with an unknown cryptID to fail")
+	}
+
+	backend.healthErr.Store(2)
+	if err := client.HealthCheck(); err == nil {
+		t.Fatal("Expected the health check to fail")
+	}
+
+	var buf bytes.Buffer
+	if err := client.Metrics().WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		`vlock_operations_total{operation="protect_text",crypt_id="SSN_Internal",result="success"} 1`,
+		`vlock_operations_total{operation="access_text",crypt_id="SSN_Internal",result="success"} 1`,
+		`vlock_operations_total{operation="access_text",crypt_id="other",result="failure"} 1`,
+		`vlock_operation_duration_seconds_bucket{operation="access_text",le="+Inf"} 2`,
+		`vlock_operation_duration_seconds_count{operation="protect_text"} 1`,
+		`vlock_retries_total{call="protect"} 1`,
+		`vlock_retries_total{call="health check"} 1`,
+		"vlock_health_check_failures_total 1",
+		"vlock_circuit_state 0",
+		"vlock_initialized 1",
+		"vlock_healthy 0",
+		"# TYPE vlock_operation_duration_seconds histogram",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Metrics missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Made_Up_ID") {
+		t.Error("Unknown cryptIDs should not become label values")
+	}
+}
+
+func TestMetricsHistogramIsCumulative(t *testing.T) {
+	client := newBytesTestClient(t)
+	m := client.Metrics()
+	m.observeOperation(operationEvent{start: time.Now().Add(-30 * time.Millisecond), op: AuditProtectBytes}, "BINARY_Internal")
+	m.observeOperation(operationEvent{start: time.Now().Add(-time.Minute), op: AuditProtectBytes}, "BINARY_Internal")
+
+	var buf bytes.Buffer
+	if err := m.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	for _, want := range []string{
+		`vlock_operation_duration_seconds_bucket{operation="protect_bytes",le="0.025"} 0`,
+		`vlock_operation_duration_seconds_bucket{operation="protect_bytes",le="0.05"} 1`,
+		`vlock_operation_duration_seconds_bucket{operation="protect_bytes",le="10"} 1`,
+		`vlock_operation_duration_seconds_bucket{operation="protect_bytes",le="+Inf"} 2`,
+	} {
+		if !strings.Contains(buf.String(), want+"\n") {
+			t.Errorf("Metrics missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	client := newBytesTestClient(t)
+	server := httptest.NewServer(client.Metrics())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != MetricsContentType {
+		t.Errorf("Unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	if !strings.Contains(body.String(), "vlock_initialized 1\n") {
+		t.Errorf("Unexpected body:\n%s", body.String())
+	}
+
+	resp, err = http.Post(server.URL+"/metrics", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", resp.StatusCode)
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got := quoteLabel("a\"b\\c\nd"); got != `"a\"b\\c\nd"` {
+		t.Errorf("quoteLabel = %s", got)
+	}
+}
+
+func TestMetricsCryptIDWithoutSecurityConfig(t *testing.T) {
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = ""
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	// Without vsconfig.xml the calls fail, but they are counted all the same
+	for _, cryptID := range []string{"", "Caller_Chosen_1", "Caller_Chosen_2"} {
+		client.ProtectText(ctx, cryptID, "123-45-6789")
+	}
+
+	var buf bytes.Buffer
+	if err := client.Metrics().WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus failed: %v", err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "Caller_Chosen") {
+		t.Errorf("cryptIDs without a vsconfig.xml should not become label values:\n%s", out)
+	}
+	for _, want := range []string{
+		`vlock_operations_total{operation="protect_text",crypt_id="SSN_Internal",result="failure"} 1`,
+		`vlock_operations_total{operation="protect_text",crypt_id="other",result="failure"} 2`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("Metrics missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// ApplyConfig switches the client to cfg, typically one delivered by a config.Watcher
+//
+// NetworkTimeout, LogLevel, LogFile, DefaultCryptID and the retry settings take effect
+// in place. Any other change, including a changed vsconfig.xml, reinitializes the client
+// and runs a health check; if either fails, the previous configuration is restored
+// and the error is returned. The restore is not bound by ctx, which may be what ended
+// the attempt; Config.NetworkTimeout bounds each of its backend calls instead
+// A running health monitor is restarted after the client is reinitialized
+func (c *Client) ApplyConfig(cfg *config.Config) error {
+	return c.ApplyConfigContext(context.Background(), cfg)
+}
+
+// ApplyConfigContext is ApplyConfig with cancellation and a deadline
+func (c *Client) ApplyConfigContext(ctx context.Context, cfg *config.Config) error {
+	if cfg == nil {
+		return fmt.Errorf("config cannot be nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	restarted, err := c.applyConfig(ctx, cfg)
+	if restarted {
+		c.restartHealthMonitor()
+	}
+	return err
+}
+
+// applyConfig does the work of ApplyConfigContext and reports whether the backend was restarted
+func (c *Client) applyConfig(ctx context.Context, cfg *config.Config) (restarted bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reinitialized := false
+	defer func() {
+		if err != nil {
+			c.logger.Error("configuration not applied", errorAttrs(err)...)
+		} else {
+			c.logger.Info("configuration applied", "reinitialized", reinitialized)
+		}
+		c.auditLocked(ctx, operationEvent{op: AuditApplyConfig, err: err})
+	}()
+
+	// Parse vsconfig.xml up front so a broken file never disturbs the running client
+	var security *config.SecurityConfig
+	if cfg.XMLConfigPath != "" {
+		if security, err = loadSecurityConfig(cfg.XMLConfigPath); err != nil {
+			return false, fmt.Errorf("failed to load security configuration: %w", err)
+		}
+	}
+
+	old := c.config
+	if !c.initialized || !reinitRequired(old, cfg) && reflect.DeepEqual(security, c.security) {
+		c.config = cfg
+		if c.initialized {
+			c.security = security
+			c.rememberAuditPolicyLocked(security)
+		}
+		c.applyHotLocked(old)
+		return false, nil
+	}
+
+	c.config = cfg
+	if err := c.restartLocked(ctx); err != nil {
+		c.config = old
+		// ctx may be what ended the attempt; the rollback gets its own NetworkTimeout per call
+		if rollbackErr := c.restartLocked(context.WithoutCancel(ctx)); rollbackErr != nil {
+			return true, errors.Join(
+				fmt.Errorf("new configuration failed: %w", err),
+				fmt.Errorf("restoring the previous configuration failed: %w", rollbackErr))
+		}
+		return true, fmt.Errorf("new configuration failed, previous configuration restored: %w", err)
+	}
+	reinitialized = true
+	c.applyHotLocked(old)
+	return true, nil
+}
+
+// restartLocked reinitializes the client and checks its health
+func (c *Client) restartLocked(ctx context.Context) error {
+	if err := c.reinitializeLocked(ctx); err != nil {
+		return err
+	}
+	if err := c.performHealthCheck(ctx); err != nil {
+		c.setHealthyLocked(false, err)
+		return fmt.Errorf("health check failed after reinitialization: %w", err)
+	}
+	return nil
+}
+
+// applyHotLocked brings the settings derived from the configuration in line with c.config
+func (c *Client) applyHotLocked(old *config.Config) {
+	cfg := c.config
+	if !c.customRetry {
+		c.retry = retryPolicyFromConfig(cfg)
+	}
+	if c.configLogger && (cfg.LogLevel != old.LogLevel || cfg.LogFile != old.LogFile ||
+		cfg.AppName != old.AppName || cfg.AppEnv != old.AppEnv ||
+		loggingConfigured(cfg) != loggingConfigured(old)) {
+		if c.logFile != nil {
+			c.logFile.Close()
+		}
+		c.logger, c.logFile = newConfigLogger(cfg)
+	}
+}
+
+// reinitRequired reports whether moving from old to cfg changes settings the backend was initialized with
+func reinitRequired(old, cfg *config.Config) bool {
+	a, b := *old, *cfg
+	for _, c := range []*config.Config{&a, &b} {
+		// Settings read by the client on each use
+		c.NetworkTimeout = 0
+		c.LogLevel = 0
+		c.LogFile = ""
+		c.DefaultCryptID = ""
+		c.RetryMaxAttempts = 0
+		c.RetryInitialBackoffMs = 0
+		c.RetryMaxBackoffMs = 0
+		c.RetryMaxElapsedMs = 0
+		c.ConfigFilePath = ""
+		c.Sources = nil
+	}
+	return !reflect.DeepEqual(a, b)
+}
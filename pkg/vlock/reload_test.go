@@ -0,0 +1,259 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// configCheckingBackend fails health checks while initialized with a rejected shared secret
+// and takes 200ms to initialize with a slow one
+type configCheckingBackend struct {
+	*MockBackend
+	inits    atomic.Int32
+	rejected atomic.Bool
+}
+
+func (b *configCheckingBackend) Init(cfg *config.Config) error {
+	b.inits.Add(1)
+	if cfg.DEKSharedSecret == "slow" {
+		time.Sleep(200 * time.Millisecond)
+	}
+	b.rejected.Store(cfg.DEKSharedSecret == "rejected")
+	return b.MockBackend.Init(cfg)
+}
+
+func (b *configCheckingBackend) HealthCheck() error {
+	if b.rejected.Load() {
+		return NewVoltageError(int(ErrAuthenticationFailed), "bad credentials")
+	}
+	return b.MockBackend.HealthCheck()
+}
+
+func newReloadTestClient(t *testing.T) (*Client, *configCheckingBackend) {
+	t.Helper()
+	backend := &configCheckingBackend{MockBackend: NewMockBackend()}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, backend
+}
+
+func TestApplyConfigHotFields(t *testing.T) {
+	client, backend := newReloadTestClient(t)
+
+	cfg := *client.Config()
+	cfg.NetworkTimeout = 42
+	cfg.RetryMaxAttempts = 7
+	cfg.LogLevel = 3
+	cfg.LogFile = filepath.Join(t.TempDir(), "vlock.log")
+	if err := client.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+
+	if backend.inits.Load() != 1 {
+		t.Errorf("Hot settings should not reinitialize, got %d inits", backend.inits.Load())
+	}
+	if client.Config().NetworkTimeout != 42 || client.networkTimeout().Seconds() != 42 {
+		t.Errorf("NetworkTimeout not applied: %v", client.networkTimeout())
+	}
+	if client.retry.MaxAttempts != 7 {
+		t.Errorf("Retry policy not applied: %+v", client.retry)
+	}
+	if !client.logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("LogLevel 3 should enable debug logging")
+	}
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Errorf("ProtectText after hot apply failed: %v", err)
+	}
+}
+
+func TestApplyConfigKeepsCustomRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	cfg := *client.Config()
+	cfg.RetryMaxAttempts = 1
+	if err := client.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+	if client.retry.MaxAttempts != 5 {
+		t.Errorf("WithRetryPolicy should win over the configuration, got %+v", client.retry)
+	}
+}
+
+func TestApplyConfigReinitializes(t *testing.T) {
+	client, backend := newReloadTestClient(t)
+
+	cfg := *client.Config()
+	cfg.DEKSharedSecret = "rotated_secret"
+	if err := client.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+	if backend.inits.Load() != 2 {
+		t.Errorf("Changed credentials should reinitialize, got %d inits", backend.inits.Load())
+	}
+	if client.Config().DEKSharedSecret != "rotated_secret" || !client.IsHealthy() {
+		t.Error("New configuration not in effect")
+	}
+}
+
+func TestApplyConfigRollsBack(t *testing.T) {
+	client, backend := newReloadTestClient(t)
+	original := client.Config()
+
+	cfg := *original
+	cfg.DEKSharedSecret = "rejected"
+	err := client.ApplyConfig(&cfg)
+	if err == nil || !strings.Contains(err.Error(), "previous configuration restored") {
+		t.Fatalf("Expected a rolled back failure, got %v", err)
+	}
+	if !errors.Is(err, &VoltageError{Code: ErrAuthenticationFailed}) {
+		t.Errorf("Expected the health check error to be wrapped, got %v", err)
+	}
+
+	if client.Config() != original {
+		t.Error("Previous configuration not restored")
+	}
+	if backend.inits.Load() != 3 || !client.IsHealthy() || !client.IsInitialized() {
+		t.Errorf("Expected a healthy client after rollback (%d inits)", backend.inits.Load())
+	}
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Errorf("ProtectText after rollback failed: %v", err)
+	}
+}
+
+func TestApplyConfigRollsBackAfterDeadline(t *testing.T) {
+	client, backend := newReloadTestClient(t)
+	original := client.Config()
+
+	cfg := *original
+	cfg.DEKSharedSecret = "slow"
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := client.ApplyConfigContext(ctx, &cfg)
+	if err == nil || !strings.Contains(err.Error(), "previous configuration restored") {
+		t.Fatalf("Expected a rolled back failure, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be reported, got %v", err)
+	}
+
+	// The rollback outlives ctx and waits for the timed out Init before starting over
+	if client.Config() != original || !client.IsHealthy() || !client.IsInitialized() {
+		t.Fatalf("Expected a healthy client after rollback (%d inits)", backend.inits.Load())
+	}
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Errorf("ProtectText after rollback failed: %v", err)
+	}
+}
+
+func TestApplyConfigRestartsHealthMonitor(t *testing.T) {
+	backend := &configCheckingBackend{MockBackend: NewMockBackend()}
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend),
+		WithHealthMonitor(HealthMonitorConfig{Interval: time.Hour, ReinitializeAfter: 1}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	client.monitorMu.Lock()
+	before := client.monitor
+	client.monitorMu.Unlock()
+
+	cfg := *client.Config()
+	cfg.DEKSharedSecret = "rotated_secret"
+	if err := client.ApplyConfig(&cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+
+	client.monitorMu.Lock()
+	after := client.monitor
+	client.monitorMu.Unlock()
+	if after == nil || after == before {
+		t.Error("Expected a fresh health monitor after reinitialization")
+	}
+}
+
+func TestApplyConfigSecurityChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vsconfig.xml")
+	writeRotationConfig(t, path, "v1")
+
+	backend := &configCheckingBackend{MockBackend: NewMockBackend()}
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = path
+	client, err := NewClient(cfg, WithBackend(backend))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	// A broken vsconfig.xml is rejected without touching the client
+	if err := os.WriteFile(path, []byte("<VoltageSecurityConfiguration>"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := client.ApplyConfig(cfg); err == nil {
+		t.Fatal("Expected a broken vsconfig.xml to be rejected")
+	}
+	if backend.inits.Load() != 1 {
+		t.Errorf("A rejected vsconfig.xml should not reinitialize, got %d inits", backend.inits.Load())
+	}
+
+	// A rotated key in the same file reinitializes
+	writeRotationConfig(t, path, "v2", "v1")
+	if err := client.ApplyConfig(cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+	if backend.inits.Load() != 2 {
+		t.Errorf("A changed vsconfig.xml should reinitialize, got %d inits", backend.inits.Load())
+	}
+	_, version, err := client.ProtectTextVersioned(context.Background(), "", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectTextVersioned failed: %v", err)
+	}
+	if version != "v2" {
+		t.Errorf("Expected the rotated key version, got %q", version)
+	}
+
+	// Applying the same configuration again is a no-op
+	if err := client.ApplyConfig(cfg); err != nil {
+		t.Fatalf("ApplyConfig failed: %v", err)
+	}
+	if backend.inits.Load() != 2 {
+		t.Errorf("An unchanged configuration should not reinitialize, got %d inits", backend.inits.Load())
+	}
+}
+
+func TestApplyConfigRejectsInvalid(t *testing.T) {
+	client, _ := newReloadTestClient(t)
+	cfg := *client.Config()
+	cfg.AppName = ""
+	if err := client.ApplyConfig(&cfg); err == nil {
+		t.Error("Expected an invalid configuration to be rejected")
+	}
+	if err := client.ApplyConfig(nil); err == nil {
+		t.Error("Expected a nil configuration to be rejected")
+	}
+}
@@ -0,0 +1,226 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// RetryPolicy controls how the client retries transient Voltage errors
+// An error is retried when it is a *VoltageError whose IsRetryable() reports true
+// (ErrNetworkTimeout, ErrConnectionFailed, ErrServiceUnavailable)
+type RetryPolicy struct {
+	MaxAttempts    int           // Total attempts including the first; 0 or 1 disables retries
+	InitialBackoff time.Duration // Delay before the first retry
+	MaxBackoff     time.Duration // Upper bound for a single delay, 0 for no bound
+	Multiplier     float64       // Growth factor between delays, defaults to 2
+	Jitter         float64       // Randomization fraction in [0, 1], applied as ±Jitter*delay
+	MaxElapsedTime time.Duration // Overall budget for all attempts and delays, 0 for no limit
+}
+
+// DefaultRetryPolicy returns the policy used by NewConfig's default settings
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// NoRetry is a policy that performs every operation exactly once
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// retryPolicyFromConfig builds the policy described by the fp_retry* settings
+func retryPolicyFromConfig(cfg *config.Config) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = cfg.RetryMaxAttempts
+	policy.InitialBackoff = time.Duration(cfg.RetryInitialBackoffMs) * time.Millisecond
+	policy.MaxBackoff = time.Duration(cfg.RetryMaxBackoffMs) * time.Millisecond
+	policy.MaxElapsedTime = time.Duration(cfg.RetryMaxElapsedMs) * time.Millisecond
+	return policy
+}
+
+// WithRetryPolicy overrides the retry policy derived from the configuration
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) error {
+		if err := policy.validate(); err != nil {
+			return err
+		}
+		c.retry = policy
+		c.customRetry = true
+		return nil
+	}
+}
+
+// validate checks the policy for values that cannot be honored
+func (p RetryPolicy) validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("retry policy: MaxAttempts cannot be negative")
+	case p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.MaxElapsedTime < 0:
+		return fmt.Errorf("retry policy: durations cannot be negative")
+	case p.Multiplier != 0 && p.Multiplier < 1:
+		return fmt.Errorf("retry policy: Multiplier must be at least 1")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("retry policy: Jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// backoff returns the delay before retry number retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		delay *= multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+
+	// Jitter before clamping so that MaxBackoff bounds the actual delay
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	return time.Duration(delay)
+}
+
+// isRetryable reports whether err is a transient Voltage error
+func isRetryable(err error) bool {
+	var voltageErr *VoltageError
+	return errors.As(err, &voltageErr) && voltageErr.IsRetryable()
+}
+
+// invoke performs a backend call on behalf of a client method
+// Each attempt is bounded by ctx or, if ctx has no deadline, by Config.NetworkTimeout;
+// retryable failures are retried per the client's RetryPolicy until ctx ends or
+// MaxElapsedTime, which bounds all attempts together, runs out. Every attempt passes
+// through the circuit breaker if one is configured
+//
+// Backend calls cannot be interrupted, so an attempt that timed out is only retried once
+// the abandoned call and its abandon function have returned; the backend never runs two
+// attempts of the same call at once. If the abandoned call does not return within another
+// Config.NetworkTimeout, the timeout is returned without retrying
+func invoke[T any](ctx context.Context, c *Client, op string, fn func() (T, error), abandon func(T, error)) (T, error) {
+	value, err, _ := invokeSettled(ctx, c, op, fn, abandon)
+	return value, err
+}
+
+// invokeSettled is invoke that also returns, if the last attempt timed out, a channel closed
+// once that abandoned call and its abandon function have returned, and nil otherwise
+func invokeSettled[T any](ctx context.Context, c *Client, op string, fn func() (T, error), abandon func(T, error)) (T, error, <-chan struct{}) {
+	policy := c.retry
+	start := time.Now()
+
+	_, callerDeadline := ctx.Deadline()
+	if policy.MaxElapsedTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.MaxElapsedTime)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		if c.breaker != nil {
+			if err := c.breaker.allow(); err != nil {
+				c.logger.Debug("voltage operation rejected by circuit breaker", "op", op)
+				var zero T
+				return zero, err, nil
+			}
+		}
+
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout := c.networkTimeout(); timeout > 0 && !callerDeadline {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		value, err, settled := callContextSettled(attemptCtx, op, fn, abandon)
+		cancel()
+
+		if c.breaker != nil {
+			if state, changed := c.breaker.record(err); changed {
+				c.logger.Warn("circuit breaker state changed", "op", op, "state", state.String())
+			}
+		}
+
+		if err == nil {
+			c.logger.Log(ctx, LevelTrace, "voltage operation completed", "op", op, "attempts", attempt, "duration", time.Since(start))
+			return value, nil, nil
+		}
+
+		if !isRetryable(err) {
+			c.logger.Debug("voltage operation failed", append([]any{"op", op, "attempts", attempt}, errorAttrs(err)...)...)
+			return value, err, settled
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			c.logger.Error("voltage operation failed", append([]any{"op", op, "attempts", attempt}, errorAttrs(err)...)...)
+			return value, err, settled
+		}
+
+		delay := policy.backoff(attempt)
+		if policy.MaxElapsedTime > 0 && time.Since(start)+delay > policy.MaxElapsedTime {
+			c.logger.Error("voltage operation failed; retry budget exhausted", append([]any{"op", op, "attempts", attempt}, errorAttrs(err)...)...)
+			return value, err, settled
+		}
+
+		if settled != nil && !c.awaitAbandoned(ctx, settled) {
+			c.logger.Error("voltage operation failed; timed out call still running", append([]any{"op", op, "attempts", attempt}, errorAttrs(err)...)...)
+			return value, err, settled
+		}
+
+		c.metrics.observeRetry(op)
+		traceRetry(ctx)
+		c.logger.Warn("retrying voltage operation", append([]any{"op", op, "attempt", attempt, "delay", delay}, errorAttrs(err)...)...)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return value, err, settled
+		}
+	}
+}
+
+// awaitAbandoned waits for a timed-out call to settle, for at most Config.NetworkTimeout
+// It reports whether the call settled before that or ctx ended
+func (c *Client) awaitAbandoned(ctx context.Context, settled <-chan struct{}) bool {
+	wait := c.networkTimeout()
+	if wait <= 0 {
+		wait = time.Duration(config.NewConfig().NetworkTimeout) * time.Second
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-settled:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// invokeErr is invoke for backend calls that only return an error
+func invokeErr(ctx context.Context, c *Client, op string, fn func() error, abandon func(error)) error {
+	var onAbandon func(struct{}, error)
+	if abandon != nil {
+		onAbandon = func(_ struct{}, err error) { abandon(err) }
+	}
+	_, err := invoke(ctx, c, op, func() (struct{}, error) {
+		return struct{}{}, fn()
+	}, onAbandon)
+	return err
+}
@@ -0,0 +1,243 @@
+package vlock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// flakyBackend wraps MockBackend and fails the first N protect calls and health checks
+type flakyBackend struct {
+	*MockBackend
+	failures  int32
+	failWith  *VoltageError
+	protects  atomic.Int32
+	healthErr atomic.Int32
+}
+
+func (b *flakyBackend) Protect(cryptID, plaintext string) (string, error) {
+	if b.protects.Add(1) <= b.failures {
+		return "", b.failWith
+	}
+	return b.MockBackend.Protect(cryptID, plaintext)
+}
+
+func (b *flakyBackend) HealthCheck() error {
+	if b.healthErr.Load() > 0 {
+		b.healthErr.Add(-1)
+		return b.failWith
+	}
+	return b.MockBackend.HealthCheck()
+}
+
+func newFlakyClient(t *testing.T, backend *flakyBackend, policy RetryPolicy) *Client {
+	t.Helper()
+	backend.MockBackend = NewMockBackend()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	backend := &flakyBackend{failures: 2, failWith: NewVoltageError(int(ErrServiceUnavailable), "busy")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("Expected success after retries, got: %v", err)
+	}
+	if got := backend.protects.Load(); got != 3 {
+		t.Errorf("Expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	backend := &flakyBackend{failures: 5, failWith: NewVoltageError(int(ErrConnectionFailed), "refused")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	_, err := client.ProtectText(context.Background(), "", "123-45-6789")
+	if !errors.Is(err, backend.failWith) {
+		t.Fatalf("Expected ErrConnectionFailed, got: %v", err)
+	}
+	if got := backend.protects.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+}
+
+func TestRetrySkipsPermanentErrors(t *testing.T) {
+	backend := &flakyBackend{failures: 5, failWith: NewVoltageError(int(ErrAuthenticationFailed), "denied")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond})
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := backend.protects.Load(); got != 1 {
+		t.Errorf("Expected a single attempt for a permanent error, got %d", got)
+	}
+}
+
+func TestRetryHealthCheck(t *testing.T) {
+	backend := &flakyBackend{failWith: NewVoltageError(int(ErrNetworkTimeout), "slow")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	backend.healthErr.Store(2)
+	if err := client.HealthCheck(); err != nil {
+		t.Errorf("Expected health check to succeed after retries, got: %v", err)
+	}
+}
+
+func TestRetryHonorsContextAndElapsedBudget(t *testing.T) {
+	backend := &flakyBackend{failures: 100, failWith: NewVoltageError(int(ErrServiceUnavailable), "busy")}
+	client := newFlakyClient(t, backend, RetryPolicy{MaxAttempts: 100, InitialBackoff: 20 * time.Millisecond, Multiplier: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.ProtectText(ctx, "", "123-45-6789"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Retries should stop when the context ends, took %v", elapsed)
+	}
+
+	backend.protects.Store(0)
+	client.retry = RetryPolicy{MaxAttempts: 100, InitialBackoff: 20 * time.Millisecond, Multiplier: 1, MaxElapsedTime: 50 * time.Millisecond}
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if got := backend.protects.Load(); got > 4 {
+		t.Errorf("Expected MaxElapsedTime to bound attempts, got %d", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("Retry %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("Jittered delay %v outside [50ms, 150ms]", got)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(10); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("Jittered delay %v outside [500ms, MaxBackoff]", got)
+		}
+	}
+}
+
+func TestRetryPolicyValidation(t *testing.T) {
+	invalid := []RetryPolicy{
+		{MaxAttempts: -1},
+		{InitialBackoff: -time.Second},
+		{Multiplier: 0.5},
+		{Jitter: 1.5},
+	}
+	for _, policy := range invalid {
+		if _, err := NewClient(newBackendTestConfig(), WithRetryPolicy(policy)); err == nil {
+			t.Errorf("Expected error for policy %+v", policy)
+		}
+	}
+}
+
+func TestRetryPolicyFromConfig(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.RetryMaxAttempts = 5
+	cfg.RetryInitialBackoffMs = 250
+	cfg.RetryMaxBackoffMs = 4000
+	cfg.RetryMaxElapsedMs = 10000
+
+	policy := retryPolicyFromConfig(cfg)
+	if policy.MaxAttempts != 5 || policy.InitialBackoff != 250*time.Millisecond ||
+		policy.MaxBackoff != 4*time.Second || policy.MaxElapsedTime != 10*time.Second {
+		t.Errorf("Unexpected policy from config: %+v", policy)
+	}
+}
+
+// slowBackend wraps MockBackend and delays protect calls, tracking how many overlap
+type slowBackend struct {
+	*MockBackend
+	delays      []time.Duration
+	calls       atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (b *slowBackend) Protect(cryptID, plaintext string) (string, error) {
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	if n > b.maxInFlight.Load() {
+		b.maxInFlight.Store(n)
+	}
+	if call := int(b.calls.Add(1)); call <= len(b.delays) {
+		time.Sleep(b.delays[call-1])
+	}
+	return b.MockBackend.Protect(cryptID, plaintext)
+}
+
+func TestRetryWaitsForAbandonedCall(t *testing.T) {
+	backend := &slowBackend{MockBackend: NewMockBackend(), delays: []time.Duration{1300 * time.Millisecond}}
+	cfg := newBackendTestConfig()
+	cfg.NetworkTimeout = 1
+
+	client, err := NewClient(cfg, WithBackend(backend), WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("Expected the retry to succeed, got: %v", err)
+	}
+	if got := backend.calls.Load(); got != 2 {
+		t.Errorf("Expected 2 attempts, got %d", got)
+	}
+	if got := backend.maxInFlight.Load(); got != 1 {
+		t.Errorf("The retry overlapped the timed out call: %d calls in flight", got)
+	}
+}
+
+func TestRetryMaxElapsedBoundsSlowAttempts(t *testing.T) {
+	backend := &slowBackend{MockBackend: NewMockBackend(), delays: []time.Duration{2 * time.Second}}
+	cfg := newBackendTestConfig()
+	cfg.NetworkTimeout = 1
+
+	policy := RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, MaxElapsedTime: 200 * time.Millisecond}
+	client, err := NewClient(cfg, WithBackend(backend), WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	_, err = client.ProtectText(context.Background(), "", "123-45-6789")
+	assertTimeout(t, err)
+	if elapsed := time.Since(start); elapsed > 700*time.Millisecond {
+		t.Errorf("MaxElapsedTime should bound the whole call, took %v", elapsed)
+	}
+	if got := backend.calls.Load(); got != 1 {
+		t.Errorf("Expected a single attempt within the budget, got %d", got)
+	}
+}
@@ -0,0 +1,99 @@
+package vlock
+
+import (
+	"time"
+)
+
+// KeyRotation is the rotation status of the current key of a cryptID
+type KeyRotation struct {
+	CryptID      string
+	KeyVersion   string
+	RotationDate time.Time // When the current key was put in service (<rotationDate>), zero if not set
+	DueDate      time.Time // RotationDate plus keyRotationIntervalDays, zero if no rotation is scheduled
+	Overdue      bool
+	DaysOverdue  int // Whole days past DueDate
+}
+
+// KeyRotationFunc is called when a cryptID's key becomes due for rotation
+type KeyRotationFunc func(rotation KeyRotation)
+
+// OnKeyRotationDue registers a callback invoked once for each key that is past its rotation date,
+// when the client is initialized and on later health monitor runs
+// Callbacks run on the same goroutine as OnHealthChange callbacks; each overdue key is also logged
+func (c *Client) OnKeyRotationDue(fn KeyRotationFunc) {
+	if fn == nil {
+		return
+	}
+	c.notifier.mu.Lock()
+	defer c.notifier.mu.Unlock()
+	c.notifier.rotationCallbacks = append(c.notifier.rotationCallbacks, fn)
+}
+
+// KeyRotationStatus reports the rotation status of every cryptID in vsconfig.xml
+// A key is due keyRotationIntervalDays after its rotationDate when keyRotationEnabled is set;
+// it returns nil if the client has not loaded a vsconfig.xml
+func (c *Client) KeyRotationStatus() []KeyRotation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keyRotationStatusLocked(time.Now())
+}
+
+// keyRotationStatusLocked computes the rotation status at now; c.mu must be held
+func (c *Client) keyRotationStatusLocked(now time.Time) []KeyRotation {
+	if c.security == nil {
+		return nil
+	}
+
+	policy := c.security.Security
+	status := make([]KeyRotation, 0, len(c.security.CryptIDs))
+	for _, spec := range c.security.CryptIDs {
+		rotation := KeyRotation{
+			CryptID:      spec.Name,
+			KeyVersion:   spec.KeyVersion,
+			RotationDate: spec.RotationDate,
+		}
+		if policy.KeyRotationEnabled && policy.KeyRotationIntervalDays > 0 && !spec.RotationDate.IsZero() {
+			rotation.DueDate = spec.RotationDate.AddDate(0, 0, policy.KeyRotationIntervalDays)
+			if now.After(rotation.DueDate) {
+				rotation.Overdue = true
+				rotation.DaysOverdue = int(now.Sub(rotation.DueDate).Hours() / 24)
+			}
+		}
+		status = append(status, rotation)
+	}
+	return status
+}
+
+// checkKeyRotation warns about overdue keys
+func (c *Client) checkKeyRotation() {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.checkKeyRotationLocked()
+}
+
+// checkKeyRotationLocked warns once about each overdue key; c.mu must be held
+func (c *Client) checkKeyRotationLocked() {
+	c.rotationMu.Lock()
+	defer c.rotationMu.Unlock()
+
+	for _, rotation := range c.keyRotationStatusLocked(time.Now()) {
+		if !rotation.Overdue {
+			continue
+		}
+		// A key is reported again only after it was rotated or its schedule changed
+		warned := rotation.KeyVersion + "@" + rotation.DueDate.Format(time.DateOnly)
+		if c.rotationWarned[rotation.CryptID] == warned {
+			continue
+		}
+		if c.rotationWarned == nil {
+			c.rotationWarned = make(map[string]string)
+		}
+		c.rotationWarned[rotation.CryptID] = warned
+		c.logger.Warn("key rotation overdue",
+			"cryptId", rotation.CryptID,
+			"keyVersion", rotation.KeyVersion,
+			"dueDate", rotation.DueDate.Format(time.DateOnly),
+			"daysOverdue", rotation.DaysOverdue)
+		c.notifier.publishRotation(rotation)
+	}
+}
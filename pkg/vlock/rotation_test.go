@@ -0,0 +1,116 @@
+package vlock
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeScheduleConfig writes a vsconfig.xml with a 30 day rotation interval where SSN_Internal's
+// key version was rotated in ssnAge days ago, TEXT_Internal's 10 days ago and EMAIL_Internal has no date
+func writeScheduleConfig(t *testing.T, path, ssnVersion string, ssnAge int) {
+	t.Helper()
+	date := func(age int) string {
+		return time.Now().UTC().AddDate(0, 0, -age).Format(time.DateOnly)
+	}
+	xml := fmt.Sprintf(`<VoltageSecurityConfiguration>
+  <cryptId name="SSN_Internal" algorithm="FPE" key="k" format="NUMERIC">
+    <keyVersion>%s</keyVersion>
+    <rotationDate>%s</rotationDate>
+  </cryptId>
+  <cryptId name="TEXT_Internal" algorithm="AES256" key="k" format="BASE64">
+    <keyVersion>v1</keyVersion>
+    <rotationDate>%s</rotationDate>
+  </cryptId>
+  <cryptId name="EMAIL_Internal" algorithm="FPE" key="k" format="ALPHANUMERIC"/>
+  <security>
+    <keyRotationEnabled>true</keyRotationEnabled>
+    <keyRotationIntervalDays>30</keyRotationIntervalDays>
+  </security>
+</VoltageSecurityConfiguration>`, ssnVersion, date(ssnAge), date(10))
+	if err := os.WriteFile(path, []byte(xml), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestKeyRotationStatus(t *testing.T) {
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeScheduleConfig(t, xmlPath, "v1", 45)
+
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = xmlPath
+	client, err := NewClient(cfg, WithBackend(NewMockBackend()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if status := client.KeyRotationStatus(); status != nil {
+		t.Errorf("Expected no status before Initialize, got %+v", status)
+	}
+
+	warnings := make(chan KeyRotation, 8)
+	client.OnKeyRotationDue(func(rotation KeyRotation) { warnings <- rotation })
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	status := client.KeyRotationStatus()
+	if len(status) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(status))
+	}
+	ssn, text, email := status[0], status[1], status[2]
+	if ssn.CryptID != "SSN_Internal" || ssn.KeyVersion != "v1" || !ssn.Overdue || ssn.DaysOverdue != 15 {
+		t.Errorf("Unexpected SSN status %+v", ssn)
+	}
+	if !ssn.DueDate.Equal(ssn.RotationDate.AddDate(0, 0, 30)) {
+		t.Errorf("Expected due date 30 days after rotation, got %v", ssn.DueDate)
+	}
+	if text.Overdue || text.DaysOverdue != 0 || text.DueDate.IsZero() {
+		t.Errorf("Unexpected TEXT status %+v", text)
+	}
+	if email.Overdue || !email.DueDate.IsZero() {
+		t.Errorf("Undated key should have no schedule, got %+v", email)
+	}
+
+	select {
+	case rotation := <-warnings:
+		if rotation.CryptID != "SSN_Internal" {
+			t.Errorf("Unexpected warning %+v", rotation)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a rotation warning")
+	}
+
+	// The same overdue key is not reported twice
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+	client.checkKeyRotation()
+
+	// Rotating the key clears the warning; when the new key falls due it is reported again
+	writeScheduleConfig(t, xmlPath, "v2", 0)
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+	writeScheduleConfig(t, xmlPath, "v2", 31)
+	if err := client.Reinitialize(); err != nil {
+		t.Fatalf("Reinitialize failed: %v", err)
+	}
+
+	select {
+	case rotation := <-warnings:
+		if rotation.KeyVersion != "v2" || rotation.DaysOverdue != 1 {
+			t.Errorf("Expected warning for overdue v2, got %+v", rotation)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a rotation warning for v2")
+	}
+	select {
+	case rotation := <-warnings:
+		t.Errorf("Unexpected extra warning %+v", rotation)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
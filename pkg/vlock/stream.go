@@ -0,0 +1,323 @@
+package vlock
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Stream framing
+//
+//	header: "VLKS" | version (1 byte) | random stream ID (16 bytes) | cryptID length (1 byte) | cryptID |
+//	        key version length (1 byte) | key version | chunk size (4 bytes, big endian)
+//	chunk:  sealed length (4 bytes, big endian) | sealed chunk
+//
+// Each sealed chunk is ProtectBytes(cryptID, SHA-256(header) | sequence (8 bytes) | final flag (1 byte) | data),
+// so chunks cannot be reordered, moved between streams or dropped, and a stream that
+// ends before its final chunk is reported as truncated
+const (
+	streamMagic    = "VLKS"
+	streamVersion  = 1
+	streamIDLength = 16
+
+	// DefaultStreamChunkSize is the plaintext size of each chunk written by ProtectStream
+	DefaultStreamChunkSize = 64 * 1024
+	// MaxStreamChunkSize bounds the chunk size accepted by WithStreamChunkSize and AccessStream
+	MaxStreamChunkSize = 16 * 1024 * 1024
+
+	streamChunkPrefix = sha256.Size + 8 + 1
+	// streamMaxOverhead bounds what the backend may add to a chunk when sealing it
+	streamMaxOverhead = 4096
+)
+
+// StreamHeader describes a stream written by ProtectStream
+type StreamHeader struct {
+	Version    int
+	StreamID   [streamIDLength]byte // Random per stream, so chunks cannot be spliced between streams
+	CryptID    string
+	KeyVersion string // keyVersion of the cryptID in vsconfig.xml when the stream was written, may be empty
+	ChunkSize  int
+}
+
+// WithStreamChunkSize sets the plaintext chunk size used by ProtectStream
+func WithStreamChunkSize(size int) ClientOption {
+	return func(c *Client) error {
+		if size <= 0 || size > MaxStreamChunkSize {
+			return fmt.Errorf("stream chunk size must be between 1 and %d", MaxStreamChunkSize)
+		}
+		c.streamChunkSize = size
+		return nil
+	}
+}
+
+// ProtectStream encrypts src into dst in authenticated chunks using a BINARY cryptID
+// If cryptID is empty, Config.DefaultCryptID is used
+// Each chunk is a separate ProtectBytes call, so retries and the circuit breaker apply per chunk
+func (c *Client) ProtectStream(ctx context.Context, cryptID string, dst io.Writer, src io.Reader) error {
+	ctx, start := c.startOperation(ctx, AuditProtectStream)
+	err := c.protectStream(ctx, cryptID, dst, src)
+	c.observe(ctx, operationEvent{start: start, op: AuditProtectStream, cryptID: cryptID, count: 1, err: err})
+	return err
+}
+
+// protectStream is ProtectStream without auditing
+func (c *Client) protectStream(ctx context.Context, cryptID string, dst io.Writer, src io.Reader) error {
+	header, err := c.newStreamHeader(cryptID)
+	if err != nil {
+		return err
+	}
+
+	rawHeader, err := header.marshal()
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(rawHeader); err != nil {
+		return fmt.Errorf("failed to write stream header: %w", err)
+	}
+	digest := sha256.Sum256(rawHeader)
+
+	reader := bufio.NewReader(src)
+	chunk := make([]byte, streamChunkPrefix+header.ChunkSize)
+	var frameLength [4]byte
+
+	for seq := uint64(0); ; seq++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := io.ReadFull(reader, chunk[streamChunkPrefix:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read stream input: %w", err)
+		}
+		final := n < header.ChunkSize
+		if !final {
+			// A full chunk is only the last one if nothing follows it
+			if _, err := reader.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return fmt.Errorf("failed to read stream input: %w", err)
+			}
+		}
+
+		copy(chunk, digest[:])
+		binary.BigEndian.PutUint64(chunk[sha256.Size:], seq)
+		chunk[sha256.Size+8] = 0
+		if final {
+			chunk[sha256.Size+8] = 1
+		}
+
+		sealed, keyVersion, err := c.protectBytes(ctx, header.CryptID, chunk[:streamChunkPrefix+n])
+		if err != nil {
+			return fmt.Errorf("failed to protect stream chunk %d: %w", seq, err)
+		}
+		// The header names one key version for the whole stream
+		if keyVersion != header.KeyVersion {
+			return NewVoltageError(int(ErrEncryptionFailed),
+				fmt.Sprintf("key of cryptId %s rotated from version %s to %s while writing stream chunk %d", header.CryptID, header.KeyVersion, keyVersion, seq))
+		}
+
+		binary.BigEndian.PutUint32(frameLength[:], uint32(len(sealed)))
+		if _, err := dst.Write(frameLength[:]); err != nil {
+			return fmt.Errorf("failed to write stream chunk %d: %w", seq, err)
+		}
+		if _, err := dst.Write(sealed); err != nil {
+			return fmt.Errorf("failed to write stream chunk %d: %w", seq, err)
+		}
+
+		if final {
+			return nil
+		}
+	}
+}
+
+// AccessStream decrypts a stream written by ProtectStream from src into dst
+// Plaintext is written chunk by chunk as it is verified; if an error is returned,
+// dst may already hold the plaintext of the chunks before the failure
+// If cryptID is empty the stream's own cryptID is used, otherwise it must match the header
+// Streams written before a key rotation are decrypted with the key version in their header,
+// which must still be a <previousKey> of the cryptID
+func (c *Client) AccessStream(ctx context.Context, cryptID string, dst io.Writer, src io.Reader) error {
+	ctx, start := c.startOperation(ctx, AuditAccessStream)
+	streamCryptID, err := c.accessStream(ctx, cryptID, dst, src)
+	if streamCryptID != "" {
+		cryptID = streamCryptID
+	}
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessStream, cryptID: cryptID, count: 1, err: err})
+	return err
+}
+
+// accessStream is AccessStream without auditing
+// It returns the cryptID named by the stream header once that has been read
+func (c *Client) accessStream(ctx context.Context, cryptID string, dst io.Writer, src io.Reader) (string, error) {
+	reader := bufio.NewReader(src)
+
+	header, rawHeader, err := readStreamHeader(reader)
+	if err != nil {
+		return "", err
+	}
+	if cryptID != "" && cryptID != header.CryptID {
+		return header.CryptID, NewVoltageError(int(ErrInvalidData),
+			fmt.Sprintf("stream was protected with cryptId %s, not %s", header.CryptID, cryptID))
+	}
+	digest := sha256.Sum256(rawHeader)
+
+	maxFrame := streamChunkPrefix + header.ChunkSize + streamMaxOverhead
+	var frameLength [4]byte
+
+	for seq := uint64(0); ; seq++ {
+		if err := ctx.Err(); err != nil {
+			return header.CryptID, err
+		}
+
+		if _, err := io.ReadFull(reader, frameLength[:]); err != nil {
+			return header.CryptID, streamReadError(seq, err)
+		}
+		length := int(binary.BigEndian.Uint32(frameLength[:]))
+		if length < streamChunkPrefix || length > maxFrame {
+			return header.CryptID, NewVoltageError(int(ErrInvalidData), fmt.Sprintf("stream chunk %d has invalid length %d", seq, length))
+		}
+		sealed := make([]byte, length)
+		if _, err := io.ReadFull(reader, sealed); err != nil {
+			return header.CryptID, streamReadError(seq, err)
+		}
+
+		// Chunks are decrypted with the key version the stream was written with
+		chunk, err := c.accessBytes(ctx, header.CryptID, header.KeyVersion, sealed)
+		if err != nil {
+			return header.CryptID, fmt.Errorf("failed to access stream chunk %d: %w", seq, err)
+		}
+		if len(chunk) < streamChunkPrefix ||
+			!bytes.Equal(chunk[:sha256.Size], digest[:]) ||
+			binary.BigEndian.Uint64(chunk[sha256.Size:]) != seq ||
+			len(chunk)-streamChunkPrefix > header.ChunkSize {
+			return header.CryptID, NewVoltageError(int(ErrDecryptionFailed), fmt.Sprintf("stream chunk %d is out of place or corrupt", seq))
+		}
+
+		if _, err := dst.Write(chunk[streamChunkPrefix:]); err != nil {
+			return header.CryptID, fmt.Errorf("failed to write stream output: %w", err)
+		}
+
+		if chunk[sha256.Size+8] == 1 {
+			if _, err := reader.Peek(1); err == nil {
+				return header.CryptID, NewVoltageError(int(ErrInvalidData), "unexpected data after the final stream chunk")
+			} else if err != io.EOF {
+				return header.CryptID, fmt.Errorf("failed to read stream input: %w", err)
+			}
+			return header.CryptID, nil
+		}
+	}
+}
+
+// ReadStreamHeader reads the header of a stream written by ProtectStream
+func ReadStreamHeader(r io.Reader) (*StreamHeader, error) {
+	header, _, err := readStreamHeader(r)
+	return header, err
+}
+
+// newStreamHeader resolves cryptID and builds the header for a new stream
+func (c *Client) newStreamHeader(cryptID string) (*StreamHeader, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkFormat(cryptID, true); err != nil {
+		return nil, err
+	}
+
+	header := &StreamHeader{
+		Version:   streamVersion,
+		CryptID:   cryptID,
+		ChunkSize: c.streamChunkSize,
+	}
+	if _, err := rand.Read(header.StreamID[:]); err != nil {
+		return nil, NewVoltageError(int(ErrEncryptionFailed), err.Error())
+	}
+	if header.ChunkSize == 0 {
+		header.ChunkSize = DefaultStreamChunkSize
+	}
+	if spec, ok := c.security.CryptID(cryptID); ok {
+		header.KeyVersion = spec.KeyVersion
+	}
+	return header, nil
+}
+
+// marshal encodes the header
+func (h *StreamHeader) marshal() ([]byte, error) {
+	if len(h.CryptID) > 255 || len(h.KeyVersion) > 255 {
+		return nil, NewVoltageError(int(ErrInvalidParameter), "cryptId and key version must be at most 255 bytes")
+	}
+
+	buf := make([]byte, 0, len(streamMagic)+1+streamIDLength+2+len(h.CryptID)+len(h.KeyVersion)+4)
+	buf = append(buf, streamMagic...)
+	buf = append(buf, byte(h.Version))
+	buf = append(buf, h.StreamID[:]...)
+	buf = append(buf, byte(len(h.CryptID)))
+	buf = append(buf, h.CryptID...)
+	buf = append(buf, byte(len(h.KeyVersion)))
+	buf = append(buf, h.KeyVersion...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(h.ChunkSize))
+	return buf, nil
+}
+
+// readStreamHeader decodes a header, returning it with its raw bytes
+func readStreamHeader(r io.Reader) (*StreamHeader, []byte, error) {
+	var raw bytes.Buffer
+	tee := io.TeeReader(r, &raw)
+
+	fixed := make([]byte, len(streamMagic)+1+streamIDLength+1)
+	if _, err := io.ReadFull(tee, fixed); err != nil {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "stream header is truncated")
+	}
+	if string(fixed[:len(streamMagic)]) != streamMagic {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "not a vlock stream")
+	}
+	header := &StreamHeader{Version: int(fixed[len(streamMagic)])}
+	if header.Version != streamVersion {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), fmt.Sprintf("unsupported stream version %d", header.Version))
+	}
+
+	copy(header.StreamID[:], fixed[len(streamMagic)+1:])
+
+	cryptID := make([]byte, fixed[len(fixed)-1])
+	var keyVersionLength [1]byte
+	if _, err := io.ReadFull(tee, cryptID); err != nil {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "stream header is truncated")
+	}
+	if _, err := io.ReadFull(tee, keyVersionLength[:]); err != nil {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "stream header is truncated")
+	}
+	rest := make([]byte, int(keyVersionLength[0])+4)
+	if _, err := io.ReadFull(tee, rest); err != nil {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "stream header is truncated")
+	}
+
+	header.CryptID = string(cryptID)
+	header.KeyVersion = string(rest[:keyVersionLength[0]])
+	header.ChunkSize = int(binary.BigEndian.Uint32(rest[keyVersionLength[0]:]))
+	if header.CryptID == "" || header.ChunkSize <= 0 || header.ChunkSize > MaxStreamChunkSize {
+		return nil, nil, NewVoltageError(int(ErrInvalidData), "stream header is invalid")
+	}
+
+	return header, raw.Bytes(), nil
+}
+
+// streamReadError reports a failure to read chunk seq, treating a premature end as truncation
+func streamReadError(seq uint64, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return NewVoltageError(int(ErrInvalidData), fmt.Sprintf("stream is truncated at chunk %d", seq))
+	}
+	return fmt.Errorf("failed to read stream chunk %d: %w", seq, err)
+}
@@ -0,0 +1,188 @@
+package vlock
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func newStreamTestClient(t *testing.T, chunkSize int) *Client {
+	t.Helper()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(NewMockBackend()), WithStreamChunkSize(chunkSize))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func protectStream(t *testing.T, client *Client, data []byte) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := client.ProtectStream(context.Background(), "BINARY_Internal", &out, bytes.NewReader(data)); err != nil {
+		t.Fatalf("ProtectStream failed: %v", err)
+	}
+	return out.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	const chunkSize = 16
+	client := newStreamTestClient(t, chunkSize)
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3 * chunkSize, 1000} {
+		data := make([]byte, size)
+		rand.Read(data)
+
+		protected := protectStream(t, client, data)
+		if size >= 8 && bytes.Contains(protected, data) {
+			t.Errorf("Size %d: protected stream contains the plaintext", size)
+		}
+
+		var out bytes.Buffer
+		if err := client.AccessStream(context.Background(), "", &out, bytes.NewReader(protected)); err != nil {
+			t.Fatalf("Size %d: AccessStream failed: %v", size, err)
+		}
+		if !bytes.Equal(out.Bytes(), data) {
+			t.Errorf("Size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestStreamHeader(t *testing.T) {
+	client := newStreamTestClient(t, 32)
+	protected := protectStream(t, client, []byte("payload"))
+
+	header, err := ReadStreamHeader(bytes.NewReader(protected))
+	if err != nil {
+		t.Fatalf("ReadStreamHeader failed: %v", err)
+	}
+	if header.Version != 1 || header.CryptID != "BINARY_Internal" || header.ChunkSize != 32 {
+		t.Errorf("Unexpected header %+v", header)
+	}
+
+	if _, err := ReadStreamHeader(bytes.NewReader([]byte("not a stream"))); err == nil {
+		t.Error("Expected error for foreign data")
+	}
+}
+
+// streamFrames splits a protected stream into its header and chunk frames
+func streamFrames(t *testing.T, protected []byte) ([]byte, [][]byte) {
+	t.Helper()
+	header, err := ReadStreamHeader(bytes.NewReader(protected))
+	if err != nil {
+		t.Fatalf("ReadStreamHeader failed: %v", err)
+	}
+	raw, _ := header.marshal()
+	rest := protected[len(raw):]
+
+	var frames [][]byte
+	for len(rest) > 0 {
+		n := 4 + int(binary.BigEndian.Uint32(rest))
+		frames = append(frames, rest[:n])
+		rest = rest[n:]
+	}
+	return raw, frames
+}
+
+func TestStreamTamperDetection(t *testing.T) {
+	client := newStreamTestClient(t, 8)
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	protected := protectStream(t, client, data)
+	header, frames := streamFrames(t, protected)
+	if len(frames) != 5 {
+		t.Fatalf("Expected 5 chunks, got %d", len(frames))
+	}
+
+	join := func(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+	otherStream := protectStream(t, client, data)
+	_, otherFrames := streamFrames(t, otherStream)
+
+	largerChunks := append([]byte(nil), header...)
+	binary.BigEndian.PutUint32(largerChunks[len(largerChunks)-4:], 9)
+
+	tests := []struct {
+		name   string
+		stream []byte
+	}{
+		{"missing final chunk", join(header, frames[0], frames[1], frames[2], frames[3])},
+		{"cut mid-chunk", protected[:len(protected)-3]},
+		{"header only", header},
+		{"reordered chunks", join(header, frames[1], frames[0], frames[2], frames[3], frames[4])},
+		{"chunk from another stream", join(header, otherFrames[0], frames[1], frames[2], frames[3], frames[4])},
+		{"altered header", join(largerChunks, frames[0], frames[1], frames[2], frames[3], frames[4])},
+		{"trailing data", join(protected, []byte{0})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := client.AccessStream(context.Background(), "", &out, bytes.NewReader(tt.stream))
+			var voltageErr *VoltageError
+			if !errors.As(err, &voltageErr) {
+				t.Fatalf("Expected a VoltageError, got: %v", err)
+			}
+			if voltageErr.Code != ErrInvalidData && voltageErr.Code != ErrDecryptionFailed {
+				t.Errorf("Unexpected error code: %v", err)
+			}
+		})
+	}
+}
+
+// failAfterReader returns the data of r, then err instead of io.EOF
+type failAfterReader struct {
+	r   io.Reader
+	err error
+}
+
+func (f *failAfterReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		err = f.err
+	}
+	return n, err
+}
+
+func TestStreamReadErrorAfterFinalChunk(t *testing.T) {
+	client := newStreamTestClient(t, 8)
+	data := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+	protected := protectStream(t, client, data)
+
+	// A read failure after the final chunk is not trailing data
+	readErr := errors.New("connection reset")
+	var out bytes.Buffer
+	err := client.AccessStream(context.Background(), "", &out, &failAfterReader{r: bytes.NewReader(protected), err: readErr})
+	if !errors.Is(err, readErr) {
+		t.Errorf("Expected the read error, got: %v", err)
+	}
+	var voltageErr *VoltageError
+	if errors.As(err, &voltageErr) {
+		t.Errorf("Expected a read error rather than a VoltageError, got: %v", err)
+	}
+}
+
+func TestStreamCryptIDChecks(t *testing.T) {
+	client := newStreamTestClient(t, 16)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	var voltageErr *VoltageError
+	if err := client.ProtectStream(ctx, "SSN_Internal", &out, bytes.NewReader([]byte("data"))); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for a text cryptID, got: %v", err)
+	}
+
+	protected := protectStream(t, client, []byte("data"))
+	if err := client.AccessStream(ctx, "TEXT_Internal", &out, bytes.NewReader(protected)); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for a mismatched cryptID, got: %v", err)
+	}
+
+	if _, err := NewClient(newBackendTestConfig(), WithStreamChunkSize(0)); err == nil {
+		t.Error("Expected error for zero chunk size")
+	}
+}
@@ -0,0 +1,98 @@
+package vlock
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Span attribute keys set by the client
+const (
+	AttrCryptID   = "vlock.crypt_id"
+	AttrOperation = "vlock.operation"
+	AttrErrorCode = "vlock.error_code"
+	AttrRetries   = "vlock.retries"
+	AttrBackend   = "vlock.backend"
+)
+
+// SpanOperationHealthCheck is the operation name of health check spans
+const SpanOperationHealthCheck = "health_check"
+
+// SpanAttribute is a key/value pair recorded on a span
+// Value is a string, int or bool
+type SpanAttribute struct {
+	Key   string
+	Value any
+}
+
+// Tracer starts a span for each client operation
+// Spans are named "vlock.<operation>", using the operation names of the audit trail
+// and SpanOperationHealthCheck. The github.com/daveaugustus/vlock/pkg/otelvlock module,
+// kept separate so vlock itself does not depend on OpenTelemetry, adapts an OpenTelemetry tracer
+type Tracer interface {
+	// Start begins a span as a child of any span in ctx and returns a context carrying it
+	Start(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span)
+}
+
+// Span is an operation in progress
+type Span interface {
+	// End finishes the span; err is the operation's result, nil on success
+	End(err error, attrs ...SpanAttribute)
+}
+
+// WithTracer makes the client trace protect, access and health check calls
+func WithTracer(tracer Tracer) ClientOption {
+	return func(c *Client) error {
+		if tracer == nil {
+			return fmt.Errorf("tracer cannot be nil")
+		}
+		c.tracer = tracer
+		return nil
+	}
+}
+
+// operationSpan is the span of a public operation, carried in its context
+type operationSpan struct {
+	span    Span
+	retries atomic.Int32 // Incremented by invoke for each retried backend call
+}
+
+type operationSpanKey struct{}
+
+// startOperation notes the start of public operation op and starts its span
+func (c *Client) startOperation(ctx context.Context, op string) (context.Context, time.Time) {
+	start := time.Now()
+	if c.tracer == nil {
+		return ctx, start
+	}
+
+	ctx, span := c.tracer.Start(ctx, "vlock."+op,
+		SpanAttribute{Key: AttrOperation, Value: op},
+		SpanAttribute{Key: AttrBackend, Value: c.backend.Version()})
+	return context.WithValue(ctx, operationSpanKey{}, &operationSpan{span: span}), start
+}
+
+// endSpan ends the span startOperation put in ctx, if any
+func endSpan(ctx context.Context, event operationEvent) {
+	s, _ := ctx.Value(operationSpanKey{}).(*operationSpan)
+	if s == nil {
+		return
+	}
+
+	attrs := []SpanAttribute{{Key: AttrRetries, Value: int(s.retries.Load())}}
+	if event.cryptID != "" {
+		attrs = append(attrs, SpanAttribute{Key: AttrCryptID, Value: event.cryptID})
+	}
+	if code, ok := errorCode(event.err); ok {
+		attrs = append(attrs, SpanAttribute{Key: AttrErrorCode, Value: int(code)})
+	}
+	s.span.End(event.err, attrs...)
+}
+
+// traceRetry counts a retried backend call on the operation's span
+func traceRetry(ctx context.Context) {
+	if s, _ := ctx.Value(operationSpanKey{}).(*operationSpan); s != nil {
+		s.retries.Add(1)
+	}
+}
@@ -0,0 +1,78 @@
+package vlock
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingTracer keeps the spans it has ended
+type recordingTracer struct {
+	mu    sync.Mutex
+	ended []*recordedSpan
+}
+
+type recordedSpan struct {
+	tracer *recordingTracer
+	name   string
+	attrs  map[string]any
+	err    error
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, attrs ...SpanAttribute) (context.Context, Span) {
+	span := &recordedSpan{tracer: t, name: name, attrs: make(map[string]any)}
+	for _, attr := range attrs {
+		span.attrs[attr.Key] = attr.Value
+	}
+	return ctx, span
+}
+
+func (s *recordedSpan) End(err error, attrs ...SpanAttribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+	s.err = err
+	s.tracer.mu.Lock()
+	s.tracer.ended = append(s.tracer.ended, s)
+	s.tracer.mu.Unlock()
+}
+
+func TestTracerRecordsRetries(t *testing.T) {
+	tracer := &recordingTracer{}
+	backend := &flakyBackend{failures: 2, failWith: NewVoltageError(int(ErrServiceUnavailable), "busy")}
+	backend.MockBackend = NewMockBackend()
+	client, err := NewClient(newBackendTestConfig(), WithBackend(backend), WithTracer(tracer),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.ProtectText(context.Background(), "", "123-45-6789"); err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+
+	if len(tracer.ended) != 1 {
+		t.Fatalf("Expected one span, got %d", len(tracer.ended))
+	}
+	span := tracer.ended[0]
+	if span.name != "vlock.protect_text" || span.err != nil {
+		t.Errorf("Unexpected span %s (%v)", span.name, span.err)
+	}
+	if span.attrs[AttrRetries] != 2 || span.attrs[AttrCryptID] != "SSN_Internal" || span.attrs[AttrBackend] != MockVersion {
+		t.Errorf("Unexpected span attributes: %v", span.attrs)
+	}
+	if _, ok := span.attrs[AttrErrorCode]; ok {
+		t.Error("Successful span should not carry an error code")
+	}
+}
+
+func TestWithTracerRejectsNil(t *testing.T) {
+	if _, err := NewClient(newBackendTestConfig(), WithTracer(nil)); err == nil {
+		t.Error("Expected WithTracer(nil) to fail")
+	}
+}
@@ -1,7 +1,10 @@
 package vlock
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -11,15 +14,41 @@ import (
 // Client represents a Voltage encryption client
 // Provides methods for initializing and managing connections to the Voltage service
 type Client struct {
-	config *config.Config
+	config         *config.Config
+	backend        Backend
+	retry          RetryPolicy
+	breaker        *circuitBreaker // nil unless WithCircuitBreaker is used
+	logger         *slog.Logger
+	logFile        *logFile  // Set when the logger writes to Config.LogFile
+	configLogger   bool      // The logger was built from the configuration, so ApplyConfig may rebuild it
+	customRetry    bool      // WithRetryPolicy was used, so ApplyConfig keeps the retry policy
+	keyVersionTags bool      // WithKeyVersionTags was used
+	auditSink      AuditSink // nil unless WithAuditSink is used
+	metrics        *Metrics
+	tracer         Tracer // nil unless WithTracer is used
+
+	// security holds the cryptIDs of vsconfig.xml, loaded on Initialize; nil without XMLConfigPath
+	security *config.SecurityConfig
+	// auditPolicy is the <audit> policy of the last vsconfig.xml read, even one the client failed to start with
+	auditPolicy *config.AuditPolicy
+
+	streamChunkSize int // Plaintext bytes per ProtectStream chunk, 0 for DefaultStreamChunkSize
+	batchSize       int // Values per backend call of ProtectBatch/AccessBatch, 0 for DefaultBatchSize
 
 	// Connection state
 	initialized bool
 	mu          sync.RWMutex
+	initPending <-chan struct{} // Closed when a timed-out Init has returned and been rolled back; guarded by mu
 
 	// Health monitoring
 	lastHealthCheck time.Time
 	healthy         bool
+	notifier        healthNotifier
+	monitorMu       sync.Mutex
+	monitor         *healthMonitor
+	monitorConfig   *HealthMonitorConfig
+	rotationMu      sync.Mutex
+	rotationWarned  map[string]string // cryptID -> key version and due date already reported overdue
 
 	// Session management
 	sessionID string
@@ -62,7 +91,9 @@ func NewClient(cfg *config.Config, opts ...ClientOption) (*Client, error) {
 		config:      cfg,
 		initialized: false,
 		healthy:     false,
+		retry:       retryPolicyFromConfig(cfg),
 	}
+	client.metrics = newMetrics(client)
 
 	// Apply functional options
 	for _, opt := range opts {
@@ -71,80 +102,147 @@ func NewClient(cfg *config.Config, opts ...ClientOption) (*Client, error) {
 		}
 	}
 
+	if client.backend == nil {
+		client.backend = defaultBackend()
+	}
+
+	if client.logger == nil {
+		client.logger, client.logFile = newConfigLogger(cfg)
+		client.configLogger = true
+	}
+
 	return client, nil
 }
 
 // Initialize establishes connection to the Voltage service and performs initial setup
 // This method must be called before using any encryption/decryption functions
-// It initializes the Voltage C library and verifies connectivity
+// It initializes the client's backend (the Voltage C library by default) and verifies connectivity
 func (c *Client) Initialize() error {
+	return c.InitializeContext(context.Background())
+}
+
+// InitializeContext is Initialize with cancellation and a deadline
+// If ctx has no deadline, Config.NetworkTimeout bounds each backend call; when it expires an
+// ErrNetworkTimeout *VoltageError is returned even if the backend call is still blocked
+// Transient failures are retried according to the client's RetryPolicy
+func (c *Client) InitializeContext(ctx context.Context) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	defer func() {
+		c.logLifecycle("initialize", err)
+		c.auditLocked(ctx, operationEvent{op: AuditInitialize, err: err})
+	}()
 
 	if c.initialized {
 		return fmt.Errorf("client already initialized")
 	}
 
-	// TODO: Initialize Voltage C library via CGO
-	// This will call voltage_init() from the C library
-	// For now, we'll simulate initialization
+	security, err := c.loadSecurity()
+	if err != nil {
+		return fmt.Errorf("failed to load security configuration: %w", err)
+	}
 
-	if err := c.initializeVoltageLibrary(); err != nil {
+	if err := c.initBackend(ctx); err != nil {
 		return fmt.Errorf("failed to initialize Voltage library: %w", err)
 	}
 
 	// Perform health check
-	if err := c.performHealthCheck(); err != nil {
+	if err := c.performHealthCheck(ctx); err != nil {
+		c.backend.Terminate()
 		return fmt.Errorf("health check failed after initialization: %w", err)
 	}
 
 	c.initialized = true
-	c.healthy = true
+	c.security = security
+	c.setHealthyLocked(true, nil)
 	c.lastHealthCheck = time.Now()
+	c.checkKeyRotationLocked()
+
+	// Start the background monitor requested with WithHealthMonitor
+	c.startConfiguredHealthMonitor()
 
 	return nil
 }
 
-// initializeVoltageLibrary initializes the Voltage C library
-// Implementation is provided by either voltage_cgo.go (with CGO) or voltage_mock.go (without CGO)
+// initBackend initializes the backend within ctx
+// If ctx ends while Init is still running, a late successful Init is rolled back;
+// the next initBackend waits for that before calling Init again. c.mu must be held
+func (c *Client) initBackend(ctx context.Context) error {
+	if c.initPending != nil {
+		if !c.awaitAbandoned(ctx, c.initPending) {
+			return NewVoltageError(int(ErrNetworkTimeout), "a timed out initialization is still running")
+		}
+		c.initPending = nil
+	}
+
+	backend, cfg := c.backend, c.config
+	_, err, pending := invokeSettled(ctx, c, "initialize", func() (struct{}, error) {
+		return struct{}{}, backend.Init(cfg)
+	}, func(_ struct{}, err error) {
+		if err == nil {
+			backend.Terminate()
+		}
+	})
+	c.initPending = pending
+	return err
+}
 
 // performHealthCheck verifies the Voltage service is accessible
-func (c *Client) performHealthCheck() error {
+func (c *Client) performHealthCheck(ctx context.Context) error {
 	if c.config == nil {
 		return fmt.Errorf("configuration not loaded")
 	}
 
-	// Call the C library health check
-	return c.performHealthCheckC()
+	err := invokeErr(ctx, c, "health check", c.backend.HealthCheck, nil)
+	if err != nil && !errors.Is(ctx.Err(), context.Canceled) {
+		c.metrics.observeHealthCheckFailure()
+	}
+	return err
 }
 
 // Close gracefully shuts down the Voltage client
 // This should be called when the client is no longer needed
 // It terminates the Voltage C library connection and cleans up resources
 func (c *Client) Close() error {
+	return c.CloseContext(context.Background())
+}
+
+// CloseContext is Close with cancellation and a deadline
+// If the deadline expires the client stays initialized so Close can be retried
+func (c *Client) CloseContext(ctx context.Context) error {
+	// Stop the monitor first; it needs c.mu to finish an in-flight check
+	c.StopHealthMonitor()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	ctx, cancel := c.withNetworkTimeout(ctx)
+	defer cancel()
+
 	if !c.initialized {
 		return nil // Already closed or never initialized
 	}
 
-	// TODO: Terminate Voltage C library via CGO
-	// This will call voltage_terminate()
-
-	if err := c.terminateVoltageLibrary(); err != nil {
-		return fmt.Errorf("failed to terminate Voltage library: %w", err)
+	if err := runContext(ctx, "terminate", c.backend.Terminate, nil); err != nil {
+		err = fmt.Errorf("failed to terminate Voltage library: %w", err)
+		c.logLifecycle("close", err)
+		c.auditLocked(ctx, operationEvent{op: AuditClose, err: err})
+		return err
 	}
 
 	c.initialized = false
-	c.healthy = false
+	c.setHealthyLocked(false, nil)
+	c.logLifecycle("close", nil)
+	c.auditLocked(ctx, operationEvent{op: AuditClose})
+
+	// Release the log file; it is reopened if the client logs again
+	if c.logFile != nil {
+		c.logFile.Close()
+	}
 
 	return nil
 }
 
-// terminateVoltageLibrary terminates the Voltage C library
-// Implementation is provided by either voltage_cgo.go (with CGO) or voltage_mock.go (without CGO)
-
 // IsInitialized returns whether the client has been initialized
 func (c *Client) IsInitialized() bool {
 	c.mu.RLock()
@@ -161,12 +259,23 @@ func (c *Client) IsHealthy() bool {
 
 // Config returns the client's configuration (read-only)
 func (c *Client) Config() *config.Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.config
 }
 
 // HealthCheck performs an on-demand health check
 // Returns an error if the service is not healthy
 func (c *Client) HealthCheck() error {
+	return c.HealthCheckContext(context.Background())
+}
+
+// HealthCheckContext is HealthCheck with cancellation and a deadline
+// If ctx has no deadline, Config.NetworkTimeout bounds each attempt
+func (c *Client) HealthCheckContext(ctx context.Context) (err error) {
+	ctx, _ = c.startOperation(ctx, SpanOperationHealthCheck)
+	defer func() { endSpan(ctx, operationEvent{op: SpanOperationHealthCheck, err: err}) }()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -174,12 +283,15 @@ func (c *Client) HealthCheck() error {
 		return fmt.Errorf("client not initialized")
 	}
 
-	if err := c.performHealthCheck(); err != nil {
-		c.healthy = false
+	if err := c.performHealthCheck(ctx); err != nil {
+		// A cancelled caller says nothing about the backend's health
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			c.setHealthyLocked(false, err)
+		}
 		return err
 	}
 
-	c.healthy = true
+	c.setHealthyLocked(true, nil)
 	c.lastHealthCheck = time.Now()
 
 	return nil
@@ -195,29 +307,169 @@ func (c *Client) LastHealthCheck() time.Time {
 // Reinitialize attempts to reinitialize the client if initialization fails or connection is lost
 // This is useful for recovery scenarios
 func (c *Client) Reinitialize() error {
+	return c.ReinitializeContext(context.Background())
+}
+
+// ReinitializeContext is Reinitialize with cancellation and a deadline
+// If ctx has no deadline, Config.NetworkTimeout is used for each backend call
+func (c *Client) ReinitializeContext(ctx context.Context) (err error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	defer func() {
+		c.logLifecycle("reinitialize", err)
+		c.auditLocked(ctx, operationEvent{op: AuditReinitialize, err: err})
+	}()
+
+	return c.reinitializeLocked(ctx)
+}
 
+// reinitializeLocked is ReinitializeContext for callers holding c.mu
+func (c *Client) reinitializeLocked(ctx context.Context) error {
 	if c.initialized {
 		// Close existing connection
-		if err := c.terminateVoltageLibrary(); err != nil {
+		terminateCtx, cancel := c.withNetworkTimeout(ctx)
+		err := runContext(terminateCtx, "terminate", c.backend.Terminate, nil)
+		cancel()
+		if err != nil {
 			return fmt.Errorf("failed to terminate before reinitialize: %w", err)
 		}
 		c.initialized = false
+		c.setHealthyLocked(false, nil)
+	}
+
+	// Reinitialize, picking up changes to vsconfig.xml
+	security, err := c.loadSecurity()
+	if err != nil {
+		return fmt.Errorf("failed to load security configuration: %w", err)
 	}
 
-	// Reinitialize
-	if err := c.initializeVoltageLibrary(); err != nil {
+	if err := c.initBackend(ctx); err != nil {
 		return fmt.Errorf("failed to reinitialize Voltage library: %w", err)
 	}
 
 	c.initialized = true
-	c.healthy = true
+	c.security = security
+	c.setHealthyLocked(true, nil)
 	c.lastHealthCheck = time.Now()
+	c.checkKeyRotationLocked()
 
 	return nil
 }
 
+// ProtectText encrypts plaintext using the given cryptID
+// If cryptID is empty, Config.DefaultCryptID is used
+// FPE cryptIDs keep the length and alphabet of plaintext unless WithKeyVersionTags is used;
+// without tags, use ProtectTextVersioned to also learn the key version, for cryptIDs whose
+// key is rotated
+// The client must be initialized before calling this method
+// If ctx has no deadline, Config.NetworkTimeout bounds each attempt
+func (c *Client) ProtectText(ctx context.Context, cryptID, plaintext string) (string, error) {
+	ctx, start := c.startOperation(ctx, AuditProtectText)
+	protected, _, err := c.protectText(ctx, cryptID, plaintext)
+	c.observe(ctx, operationEvent{start: start, op: AuditProtectText, cryptID: cryptID, count: 1, err: err})
+	return protected, err
+}
+
+// protectText is ProtectText without auditing
+// It also returns the key version that protected the value
+func (c *Client) protectText(ctx context.Context, cryptID, plaintext string) (string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return "", "", ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := c.checkFormat(cryptID, false); err != nil {
+		return "", "", err
+	}
+
+	if plaintext == "" {
+		return "", "", NewVoltageError(int(ErrInvalidData), "plaintext cannot be empty")
+	}
+
+	return c.protectLocked(ctx, cryptID, plaintext)
+}
+
+// protectLocked encrypts plaintext with the current key and returns it with that key's version,
+// tagged if WithKeyVersionTags is used
+// c.mu must be held
+func (c *Client) protectLocked(ctx context.Context, cryptID, plaintext string) (string, string, error) {
+	backend := c.backend
+	protected, err := invoke(ctx, c, "protect", func() (string, error) {
+		return backend.Protect(cryptID, plaintext)
+	}, nil)
+	if err != nil {
+		return "", "", err
+	}
+	keyVersion := c.currentKeyVersion(cryptID)
+	return c.tagKeyVersion(keyVersion, protected), keyVersion, nil
+}
+
+// AccessText decrypts ciphertext previously produced by ProtectText with the same cryptID
+// If cryptID is empty, Config.DefaultCryptID is used
+// After a key rotation, that is once the cryptID lists <previousKey> entries in vsconfig.xml,
+// the key is picked by the tag of WithKeyVersionTags; untagged ciphertext is then refused
+// with ErrKeyNotFound and needs AccessTextVersion
+// The client must be initialized before calling this method
+// If ctx has no deadline, Config.NetworkTimeout bounds each attempt
+func (c *Client) AccessText(ctx context.Context, cryptID, ciphertext string) (string, error) {
+	ctx, start := c.startOperation(ctx, AuditAccessText)
+	plaintext, err := c.accessText(ctx, cryptID, "", ciphertext)
+	c.observe(ctx, operationEvent{start: start, op: AuditAccessText, cryptID: cryptID, count: 1, err: err})
+	return plaintext, err
+}
+
+// accessText is AccessText without auditing
+// keyVersion selects the key as for AccessTextVersion
+func (c *Client) accessText(ctx context.Context, cryptID, keyVersion, ciphertext string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	cryptID, err := c.resolveCryptID(cryptID)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.checkFormat(cryptID, false); err != nil {
+		return "", err
+	}
+
+	keyVersion, ciphertext, err = c.ciphertextKeyVersion(cryptID, keyVersion, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return c.accessLocked(ctx, cryptID, keyVersion, ciphertext)
+}
+
+// resolveCryptID returns cryptID, or the configured default when cryptID is empty
+func (c *Client) resolveCryptID(cryptID string) (string, error) {
+	if cryptID != "" {
+		return cryptID, nil
+	}
+	if c.config.DefaultCryptID != "" {
+		return c.config.DefaultCryptID, nil
+	}
+	return "", NewVoltageError(int(ErrCryptIDNotFound), "no cryptID given and DefaultCryptID is not configured")
+}
+
 // GetSessionID returns the current session ID if available
 func (c *Client) GetSessionID() string {
 	c.mu.RLock()
@@ -234,6 +486,8 @@ type ClientInfo struct {
 	Healthy         bool
 	LastHealthCheck time.Time
 	SessionID       string
+	BackendVersion  string
+	CircuitState    CircuitState // Always CircuitClosed without WithCircuitBreaker
 }
 
 // Info returns current client information
@@ -241,7 +495,7 @@ func (c *Client) Info() ClientInfo {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return ClientInfo{
+	info := ClientInfo{
 		AppName:         c.config.AppName,
 		AppVersion:      c.config.AppVersion,
 		Environment:     c.config.AppEnv,
@@ -249,5 +503,10 @@ func (c *Client) Info() ClientInfo {
 		Healthy:         c.healthy,
 		LastHealthCheck: c.lastHealthCheck,
 		SessionID:       c.sessionID,
+		BackendVersion:  c.backend.Version(),
+	}
+	if c.breaker != nil {
+		info.CircuitState = c.breaker.State()
 	}
+	return info
 }
@@ -1,8 +1,16 @@
+//go:build cgo && (voltage_sdk || voltage_stub)
+// +build cgo
+// +build voltage_sdk voltage_stub
+
 package vlock
 
+// The C API is declared in include/voltage.h. Build with -tags voltage_sdk to link
+// the Voltage Protector SDK from ./lib (see voltage_cgo_sdk.go). Tests and CI can
+// use -tags voltage_stub to link the insecure stub in voltage_stub.c instead;
+// without either tag the MockBackend is the default backend (see voltage_mock.go)
+
 /*
 #cgo CFLAGS: -I${SRCDIR}/include
-#cgo LDFLAGS: -L${SRCDIR}/lib -lvoltage
 
 #include <stdlib.h>
 #include "voltage.h"
@@ -20,6 +28,42 @@ int voltage_go_health_check(char** error_msg) {
     return voltage_health_check(error_msg);
 }
 
+const char* voltage_go_get_version() {
+    return voltage_get_version();
+}
+
+int voltage_go_protect(const char* crypt_id, const char* input, char** output, char** error_msg) {
+    return voltage_protect(crypt_id, input, output, error_msg);
+}
+
+int voltage_go_access(const char* crypt_id, const char* input, char** output, char** error_msg) {
+    return voltage_access(crypt_id, input, output, error_msg);
+}
+
+int voltage_go_access_version(const char* crypt_id, const char* key_version, const char* input, char** output, char** error_msg) {
+    return voltage_access_version(crypt_id, key_version, input, output, error_msg);
+}
+
+int voltage_go_protect_bytes(const char* crypt_id, const void* input, size_t input_len, void** output, size_t* output_len, char** error_msg) {
+    return voltage_protect_bytes(crypt_id, (const unsigned char*)input, input_len, (unsigned char**)output, output_len, error_msg);
+}
+
+int voltage_go_access_bytes(const char* crypt_id, const void* input, size_t input_len, void** output, size_t* output_len, char** error_msg) {
+    return voltage_access_bytes(crypt_id, (const unsigned char*)input, input_len, (unsigned char**)output, output_len, error_msg);
+}
+
+int voltage_go_access_bytes_version(const char* crypt_id, const char* key_version, const void* input, size_t input_len, void** output, size_t* output_len, char** error_msg) {
+    return voltage_access_bytes_version(crypt_id, key_version, (const unsigned char*)input, input_len, (unsigned char**)output, output_len, error_msg);
+}
+
+int voltage_go_protect_batch(const char* crypt_id, char** inputs, size_t count, char** outputs, int* codes, char** error_msgs, char** error_msg) {
+    return voltage_protect_batch(crypt_id, (const char**)inputs, count, outputs, codes, error_msgs, error_msg);
+}
+
+int voltage_go_access_batch(const char* crypt_id, char** inputs, size_t count, char** outputs, int* codes, char** error_msgs, char** error_msg) {
+    return voltage_access_batch(crypt_id, (const char**)inputs, count, outputs, codes, error_msgs, error_msg);
+}
+
 void voltage_go_free_string(char* str) {
     if (str != NULL) {
         free(str);
@@ -28,31 +72,46 @@ void voltage_go_free_string(char* str) {
 */
 import "C"
 import (
-	"fmt"
+	"sync"
 	"unsafe"
+
+	"github.com/daveaugustus/vlock/pkg/config"
 )
 
-// initializeVoltageLibrary initializes the Voltage C library with the configuration
-// This replaces the placeholder implementation in voltage.go
-func (c *Client) initializeVoltageLibrary() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// defaultBackend returns the backend used when no WithBackend option is given
+// With CGO the Voltage C library is used
+func defaultBackend() Backend {
+	return NewCBackend()
+}
+
+// CBackend is the Backend backed by the Voltage C library
+// The C library keeps process-wide state, so only one CBackend should be initialized at a time
+type CBackend struct {
+	mu          sync.RWMutex
+	initialized bool
+}
+
+// NewCBackend creates an uninitialized C library backend
+func NewCBackend() *CBackend {
+	return &CBackend{}
+}
 
-	if c.voltageInitialized {
+// Init initializes the Voltage C library with the configuration
+func (b *CBackend) Init(cfg *config.Config) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.initialized {
 		return ErrClientAlreadyInitialized
 	}
 
-	// Get the configuration file path
-	configPath := c.config.ConfigFilePath
-	if configPath == "" {
-		// Try to construct config file path from XML config path
-		if c.config.XMLConfigPath != "" {
-			configPath = c.config.XMLConfigPath
-		} else {
-			return fmt.Errorf("no configuration file path specified")
-		}
+	configPath, err := resolveConfigPath(cfg)
+	if err != nil {
+		return err
 	}
 
+	configureLibrary(cfg)
+
 	// Convert Go string to C string
 	cConfigPath := C.CString(configPath)
 	defer C.free(unsafe.Pointer(cConfigPath))
@@ -74,20 +133,19 @@ func (c *Client) initializeVoltageLibrary() error {
 		if cErrorMsg != nil {
 			errorMsg = C.GoString(cErrorMsg)
 		}
-		return mapCErrorCode(int(result), errorMsg, nil)
+		return NewVoltageError(int(result), errorMsg)
 	}
 
-	c.voltageInitialized = true
+	b.initialized = true
 	return nil
 }
 
-// terminateVoltageLibrary terminates the Voltage C library
-// This replaces the placeholder implementation in voltage.go
-func (c *Client) terminateVoltageLibrary() error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// Terminate terminates the Voltage C library
+func (b *CBackend) Terminate() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
-	if !c.voltageInitialized {
+	if !b.initialized {
 		// Not an error - already terminated or never initialized
 		return nil
 	}
@@ -109,19 +167,19 @@ func (c *Client) terminateVoltageLibrary() error {
 		if cErrorMsg != nil {
 			errorMsg = C.GoString(cErrorMsg)
 		}
-		return mapCErrorCode(int(result), errorMsg, nil)
+		return NewVoltageError(int(result), errorMsg)
 	}
 
-	c.voltageInitialized = false
+	b.initialized = false
 	return nil
 }
 
-// performHealthCheckC performs a health check against the Voltage library
-func (c *Client) performHealthCheckC() error {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// HealthCheck performs a health check against the Voltage library
+func (b *CBackend) HealthCheck() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 
-	if !c.voltageInitialized {
+	if !b.initialized {
 		return ErrClientNotInitialized
 	}
 
@@ -142,17 +200,276 @@ func (c *Client) performHealthCheckC() error {
 		if cErrorMsg != nil {
 			errorMsg = C.GoString(cErrorMsg)
 		}
-		return mapCErrorCode(int(result), errorMsg, nil)
+		return NewVoltageError(int(result), errorMsg)
 	}
 
 	return nil
 }
 
+// Protect encrypts plaintext with the given cryptID via the Voltage C library
+func (b *CBackend) Protect(cryptID, plaintext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	return callTextOperation(cryptID, plaintext, "encryption failed", func(cCryptID, cInput *C.char, cOutput, cErrorMsg **C.char) C.int {
+		return C.voltage_go_protect(cCryptID, cInput, cOutput, cErrorMsg)
+	})
+}
+
+// Access decrypts ciphertext with the given cryptID via the Voltage C library
+func (b *CBackend) Access(cryptID, ciphertext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	return callTextOperation(cryptID, ciphertext, "decryption failed", func(cCryptID, cInput *C.char, cOutput, cErrorMsg **C.char) C.int {
+		return C.voltage_go_access(cCryptID, cInput, cOutput, cErrorMsg)
+	})
+}
+
+// AccessVersion decrypts ciphertext with a specific key version of the cryptID via the Voltage C library
+func (b *CBackend) AccessVersion(cryptID, keyVersion, ciphertext string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return "", ErrClientNotInitialized
+	}
+
+	cKeyVersion := C.CString(keyVersion)
+	defer C.free(unsafe.Pointer(cKeyVersion))
+
+	return callTextOperation(cryptID, ciphertext, "decryption failed", func(cCryptID, cInput *C.char, cOutput, cErrorMsg **C.char) C.int {
+		return C.voltage_go_access_version(cCryptID, cKeyVersion, cInput, cOutput, cErrorMsg)
+	})
+}
+
+// ProtectBytes encrypts binary data with the given cryptID via the Voltage C library
+func (b *CBackend) ProtectBytes(cryptID string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return callBytesOperation(cryptID, data, "encryption failed", func(cCryptID *C.char, cInput unsafe.Pointer, inputLen C.size_t, cOutput *unsafe.Pointer, outputLen *C.size_t, cErrorMsg **C.char) C.int {
+		return C.voltage_go_protect_bytes(cCryptID, cInput, inputLen, cOutput, outputLen, cErrorMsg)
+	})
+}
+
+// AccessBytes decrypts binary data with the given cryptID via the Voltage C library
+func (b *CBackend) AccessBytes(cryptID string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return callBytesOperation(cryptID, data, "decryption failed", func(cCryptID *C.char, cInput unsafe.Pointer, inputLen C.size_t, cOutput *unsafe.Pointer, outputLen *C.size_t, cErrorMsg **C.char) C.int {
+		return C.voltage_go_access_bytes(cCryptID, cInput, inputLen, cOutput, outputLen, cErrorMsg)
+	})
+}
+
+// AccessBytesVersion decrypts binary data with a specific key version of the cryptID via the Voltage C library
+func (b *CBackend) AccessBytesVersion(cryptID, keyVersion string, data []byte) ([]byte, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	cKeyVersion := C.CString(keyVersion)
+	defer C.free(unsafe.Pointer(cKeyVersion))
+
+	return callBytesOperation(cryptID, data, "decryption failed", func(cCryptID *C.char, cInput unsafe.Pointer, inputLen C.size_t, cOutput *unsafe.Pointer, outputLen *C.size_t, cErrorMsg **C.char) C.int {
+		return C.voltage_go_access_bytes_version(cCryptID, cKeyVersion, cInput, inputLen, cOutput, outputLen, cErrorMsg)
+	})
+}
+
+// ProtectBatch encrypts values with the given cryptID in a single Voltage C library call
+func (b *CBackend) ProtectBatch(cryptID string, values []string) ([]BatchResult, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return callBatchOperation(cryptID, values, "encryption failed", func(cCryptID *C.char, cInputs **C.char, count C.size_t, cOutputs **C.char, cCodes *C.int, cErrorMsgs **C.char, cErrorMsg **C.char) C.int {
+		return C.voltage_go_protect_batch(cCryptID, cInputs, count, cOutputs, cCodes, cErrorMsgs, cErrorMsg)
+	})
+}
+
+// AccessBatch decrypts values with the given cryptID in a single Voltage C library call
+func (b *CBackend) AccessBatch(cryptID string, values []string) ([]BatchResult, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.initialized {
+		return nil, ErrClientNotInitialized
+	}
+
+	return callBatchOperation(cryptID, values, "decryption failed", func(cCryptID *C.char, cInputs **C.char, count C.size_t, cOutputs **C.char, cCodes *C.int, cErrorMsgs **C.char, cErrorMsg **C.char) C.int {
+		return C.voltage_go_access_batch(cCryptID, cInputs, count, cOutputs, cCodes, cErrorMsgs, cErrorMsg)
+	})
+}
+
+// Version returns the version of the Voltage C library
+func (b *CBackend) Version() string {
+	return GetVoltageVersion()
+}
+
+// callTextOperation handles C string conversion and cleanup shared by the text operations
+func callTextOperation(cryptID, input, defaultErrorMsg string, call func(cCryptID, cInput *C.char, cOutput, cErrorMsg **C.char) C.int) (string, error) {
+	cCryptID := C.CString(cryptID)
+	defer C.free(unsafe.Pointer(cCryptID))
+
+	cInput := C.CString(input)
+	defer C.free(unsafe.Pointer(cInput))
+
+	// Output and error message pointers are allocated by the C library
+	var cOutput *C.char
+	var cErrorMsg *C.char
+	defer func() {
+		C.voltage_go_free_string(cOutput)
+		C.voltage_go_free_string(cErrorMsg)
+	}()
+
+	result := call(cCryptID, cInput, &cOutput, &cErrorMsg)
+
+	// Convert C error code to Go error
+	if result != 0 {
+		errorMsg := defaultErrorMsg
+		if cErrorMsg != nil {
+			errorMsg = C.GoString(cErrorMsg)
+		}
+		return "", NewVoltageError(int(result), errorMsg)
+	}
+
+	if cOutput == nil {
+		return "", NewVoltageError(int(ErrInvalidData), "library returned no output")
+	}
+
+	return C.GoString(cOutput), nil
+}
+
+// callBytesOperation handles buffer conversion and cleanup shared by the binary operations
+func callBytesOperation(cryptID string, input []byte, defaultErrorMsg string, call func(cCryptID *C.char, cInput unsafe.Pointer, inputLen C.size_t, cOutput *unsafe.Pointer, outputLen *C.size_t, cErrorMsg **C.char) C.int) ([]byte, error) {
+	cCryptID := C.CString(cryptID)
+	defer C.free(unsafe.Pointer(cCryptID))
+
+	cInput := C.CBytes(input)
+	defer C.free(cInput)
+
+	// Output buffer and error message are allocated by the C library
+	var cOutput unsafe.Pointer
+	var outputLen C.size_t
+	var cErrorMsg *C.char
+	defer func() {
+		if cOutput != nil {
+			C.free(cOutput)
+		}
+		C.voltage_go_free_string(cErrorMsg)
+	}()
+
+	result := call(cCryptID, cInput, C.size_t(len(input)), &cOutput, &outputLen, &cErrorMsg)
+
+	// Convert C error code to Go error
+	if result != 0 {
+		errorMsg := defaultErrorMsg
+		if cErrorMsg != nil {
+			errorMsg = C.GoString(cErrorMsg)
+		}
+		return nil, NewVoltageError(int(result), errorMsg)
+	}
+
+	if cOutput == nil {
+		return nil, NewVoltageError(int(ErrInvalidData), "library returned no output")
+	}
+
+	return C.GoBytes(cOutput, C.int(outputLen)), nil
+}
+
+// callBatchOperation marshals values into C arrays, makes one batch call and collects per-value results
+func callBatchOperation(cryptID string, values []string, defaultErrorMsg string, call func(cCryptID *C.char, cInputs **C.char, count C.size_t, cOutputs **C.char, cCodes *C.int, cErrorMsgs **C.char, cErrorMsg **C.char) C.int) ([]BatchResult, error) {
+	count := len(values)
+	if count == 0 {
+		return []BatchResult{}, nil
+	}
+
+	cCryptID := C.CString(cryptID)
+	defer C.free(unsafe.Pointer(cCryptID))
+
+	// The arrays live in C memory so the library may keep pointers into them during the call
+	ptrSize := C.size_t(unsafe.Sizeof((*C.char)(nil)))
+	cInputs := (**C.char)(C.calloc(C.size_t(count), ptrSize))
+	cOutputs := (**C.char)(C.calloc(C.size_t(count), ptrSize))
+	cErrorMsgs := (**C.char)(C.calloc(C.size_t(count), ptrSize))
+	cCodes := (*C.int)(C.calloc(C.size_t(count), C.size_t(unsafe.Sizeof(C.int(0)))))
+	inputs := unsafe.Slice(cInputs, count)
+	outputs := unsafe.Slice(cOutputs, count)
+	errorMsgs := unsafe.Slice(cErrorMsgs, count)
+	codes := unsafe.Slice(cCodes, count)
+
+	var cErrorMsg *C.char
+	defer func() {
+		for i := 0; i < count; i++ {
+			C.free(unsafe.Pointer(inputs[i]))
+			C.voltage_go_free_string(outputs[i])
+			C.voltage_go_free_string(errorMsgs[i])
+		}
+		C.free(unsafe.Pointer(cInputs))
+		C.free(unsafe.Pointer(cOutputs))
+		C.free(unsafe.Pointer(cErrorMsgs))
+		C.free(unsafe.Pointer(cCodes))
+		C.voltage_go_free_string(cErrorMsg)
+	}()
+
+	for i, value := range values {
+		inputs[i] = C.CString(value)
+	}
+
+	result := call(cCryptID, cInputs, C.size_t(count), cOutputs, cCodes, cErrorMsgs, &cErrorMsg)
+
+	// Convert C error code to Go error
+	if result != 0 {
+		errorMsg := defaultErrorMsg
+		if cErrorMsg != nil {
+			errorMsg = C.GoString(cErrorMsg)
+		}
+		return nil, NewVoltageError(int(result), errorMsg)
+	}
+
+	results := make([]BatchResult, count)
+	for i := range results {
+		switch {
+		case codes[i] != 0:
+			errorMsg := defaultErrorMsg
+			if errorMsgs[i] != nil {
+				errorMsg = C.GoString(errorMsgs[i])
+			}
+			results[i].Err = NewVoltageError(int(codes[i]), errorMsg)
+		case outputs[i] == nil:
+			results[i].Err = NewVoltageError(int(ErrInvalidData), "library returned no output")
+		default:
+			results[i].Value = C.GoString(outputs[i])
+		}
+	}
+	return results, nil
+}
+
 // GetVoltageVersion returns the version of the Voltage C library
 // This is a utility function for debugging and logging
 func GetVoltageVersion() string {
-	// This would call a C function to get the version
-	// For now, return a placeholder
-	// In production, you would call: C.voltage_get_version()
-	return "1.0.0-placeholder"
+	return C.GoString(C.voltage_go_get_version())
 }
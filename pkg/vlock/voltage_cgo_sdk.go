@@ -0,0 +1,22 @@
+//go:build cgo && voltage_sdk
+// +build cgo,voltage_sdk
+
+package vlock
+
+// Link against the Voltage Protector SDK in ./lib instead of the bundled stub
+// Build with: go build -tags voltage_sdk
+
+/*
+#cgo LDFLAGS: -L${SRCDIR}/lib -lvoltage
+*/
+import "C"
+
+import "github.com/daveaugustus/vlock/pkg/config"
+
+// configureLibrary is a no-op: the SDK reads everything it needs from the .cfg file
+func configureLibrary(cfg *config.Config) {}
+
+// IsMockMode returns false when linked against the Voltage Protector SDK
+func IsMockMode() bool {
+	return false
+}
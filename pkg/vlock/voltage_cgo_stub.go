@@ -0,0 +1,33 @@
+//go:build cgo && voltage_stub && !voltage_sdk
+// +build cgo,voltage_stub,!voltage_sdk
+
+package vlock
+
+// Link the bundled stub in voltage_stub.c, which provides NO security
+// Build with: go test -tags voltage_stub
+
+/*
+#include <stdlib.h>
+
+void voltage_stub_set_xml_config(const char* xml_config);
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/daveaugustus/vlock/pkg/config"
+)
+
+// configureLibrary hands XMLConfigPath to the stub, which like the MockBackend takes its
+// cryptIds from vsconfig.xml and does not need the .cfg file to exist
+func configureLibrary(cfg *config.Config) {
+	cXMLConfig := C.CString(cfg.XMLConfigPath)
+	defer C.free(unsafe.Pointer(cXMLConfig))
+	C.voltage_stub_set_xml_config(cXMLConfig)
+}
+
+// IsMockMode returns true: the stub only stands in for the Voltage library in tests
+func IsMockMode() bool {
+	return true
+}
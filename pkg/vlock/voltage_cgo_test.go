@@ -0,0 +1,181 @@
+//go:build cgo && voltage_stub && !voltage_sdk
+// +build cgo,voltage_stub,!voltage_sdk
+
+package vlock
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCBackendStub(t *testing.T) {
+	if !IsMockMode() {
+		t.Error("IsMockMode should be true for the stub")
+	}
+	if v := GetVoltageVersion(); v != "1.0.0-stub" {
+		t.Errorf("Expected stub version, got %s", v)
+	}
+
+	backend := NewCBackend()
+	xmlPath := filepath.Join(t.TempDir(), "vsconfig.xml")
+	writeRotationConfig(t, xmlPath, "v1")
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = xmlPath
+
+	if _, err := backend.Protect("SSN_Internal", "123-45-6789"); !errors.Is(err, ErrClientNotInitialized) {
+		t.Errorf("Expected ErrClientNotInitialized, got: %v", err)
+	}
+
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer backend.Terminate()
+
+	if err := backend.HealthCheck(); err != nil {
+		t.Errorf("HealthCheck failed: %v", err)
+	}
+
+	protected, err := backend.Protect("SSN_Internal", "123-45-6789")
+	if err != nil {
+		t.Fatalf("Protect failed: %v", err)
+	}
+	if len(protected) != len("123-45-6789") || protected[3] != '-' || protected == "123-45-6789" {
+		t.Errorf("Unexpected protected value %s", protected)
+	}
+
+	accessed, err := backend.Access("SSN_Internal", protected)
+	if err != nil || accessed != "123-45-6789" {
+		t.Errorf("Expected '123-45-6789', got '%s' (%v)", accessed, err)
+	}
+
+	if accessed, err := backend.AccessVersion("SSN_Internal", "v1", protected); err != nil || accessed != "123-45-6789" {
+		t.Errorf("Expected '123-45-6789' from AccessVersion, got '%s' (%v)", accessed, err)
+	}
+
+	// Errors from the C library carry their code
+	var voltageErr *VoltageError
+	if _, err := backend.Protect("", "123"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrCryptIDNotFound {
+		t.Errorf("Expected ErrCryptIDNotFound, got: %v", err)
+	}
+	if _, err := backend.Protect("CCN_Internal", "4111111111111111"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrCryptIDNotFound {
+		t.Errorf("Expected ErrCryptIDNotFound for a cryptId missing from the config, got: %v", err)
+	}
+	if _, err := backend.AccessVersion("SSN_Internal", "v0", protected); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got: %v", err)
+	}
+
+	data := []byte{0x00, 0x01, 0xfe, 0xff, 'a', 0}
+	protectedBytes, err := backend.ProtectBytes("BINARY_Internal", data)
+	if err != nil {
+		t.Fatalf("ProtectBytes failed: %v", err)
+	}
+	if bytes.Equal(protectedBytes, data) {
+		t.Error("ProtectBytes returned its input")
+	}
+	accessedBytes, err := backend.AccessBytes("BINARY_Internal", protectedBytes)
+	if err != nil || !bytes.Equal(accessedBytes, data) {
+		t.Errorf("Expected %x, got %x (%v)", data, accessedBytes, err)
+	}
+
+	batch, err := backend.ProtectBatch("SSN_Internal", []string{"123-45-6789", "987-65-4321"})
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	if batch[0].Value != protected || batch[1].Err != nil {
+		t.Errorf("Unexpected batch results %+v", batch)
+	}
+	accessedBatch, err := backend.AccessBatch("SSN_Internal", []string{batch[0].Value, batch[1].Value})
+	if err != nil || accessedBatch[0].Value != "123-45-6789" || accessedBatch[1].Value != "987-65-4321" {
+		t.Errorf("Unexpected batch round trip %+v (%v)", accessedBatch, err)
+	}
+
+	// Per-value failures come back with their own codes
+	batch, err = backend.ProtectBatch("", []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("ProtectBatch failed: %v", err)
+	}
+	for i, result := range batch {
+		if !errors.As(result.Err, &voltageErr) || voltageErr.Code != ErrCryptIDNotFound {
+			t.Errorf("Value %d: expected ErrCryptIDNotFound, got: %v", i, result.Err)
+		}
+	}
+}
+
+func TestCBackendStubWithoutConfigFile(t *testing.T) {
+	// Like the MockBackend, the stub does not need the .cfg file to exist
+	backend := NewCBackend()
+	cfg := newBackendTestConfig()
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := backend.Protect("SSN_Internal", "123-45-6789"); err != nil {
+		t.Errorf("Protect failed: %v", err)
+	}
+	backend.Terminate()
+
+	cfg.XMLConfigPath = ""
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer backend.Terminate()
+	var voltageErr *VoltageError
+	if _, err := backend.Protect("SSN_Internal", "123-45-6789"); !errors.As(err, &voltageErr) || voltageErr.Code != ErrCryptIDNotFound {
+		t.Errorf("Expected ErrCryptIDNotFound without vsconfig.xml, got: %v", err)
+	}
+}
+
+func TestCBackendStubKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "vsconfig.xml")
+	cfgPath := filepath.Join(dir, "voltageprotector.cfg")
+	writeRotationConfig(t, xmlPath, "v1")
+	if err := os.WriteFile(cfgPath, []byte("[ProtectorConfig]\nXMLConfig="+xmlPath+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	backend := NewCBackend()
+	cfg := newBackendTestConfig()
+	cfg.XMLConfigPath = filepath.Join(dir, "missing.xml")
+	var voltageErr *VoltageError
+	if err := backend.Init(cfg); !errors.As(err, &voltageErr) || voltageErr.Code != ErrConfigNotFound {
+		t.Fatalf("Expected ErrConfigNotFound, got: %v", err)
+	}
+
+	// Without XMLConfigPath the .cfg file points the stub at the XML configuration
+	cfg.ConfigFilePath, cfg.XMLConfigPath = cfgPath, ""
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	protected, err := backend.Protect("SSN_Internal", "123-45-6789")
+	if err != nil {
+		t.Fatalf("Protect failed: %v", err)
+	}
+	data := []byte("binary payload")
+	protectedBytes, err := backend.ProtectBytes("BINARY_Internal", data)
+	if err != nil {
+		t.Fatalf("ProtectBytes failed: %v", err)
+	}
+	backend.Terminate()
+
+	writeRotationConfig(t, xmlPath, "v2", "v1")
+	if err := backend.Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer backend.Terminate()
+
+	if accessed, err := backend.Access("SSN_Internal", protected); err != nil || accessed == "123-45-6789" {
+		t.Errorf("The current key should not decrypt v1 ciphertext, got '%s' (%v)", accessed, err)
+	}
+	if accessed, err := backend.AccessVersion("SSN_Internal", "v1", protected); err != nil || accessed != "123-45-6789" {
+		t.Errorf("Expected '123-45-6789' from AccessVersion, got '%s' (%v)", accessed, err)
+	}
+	if accessed, err := backend.AccessBytesVersion("BINARY_Internal", "v1", protectedBytes); err != nil || !bytes.Equal(accessed, data) {
+		t.Errorf("Expected %q from AccessBytesVersion, got %q (%v)", data, accessed, err)
+	}
+	if _, err := backend.AccessBytesVersion("BINARY_Internal", "v3", protectedBytes); !errors.As(err, &voltageErr) || voltageErr.Code != ErrKeyNotFound {
+		t.Errorf("Expected ErrKeyNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,469 @@
+//go:build cgo && voltage_stub && !voltage_sdk
+
+/*
+ * voltage_stub.c - minimal stand-in for libvoltage
+ *
+ * Compiled into cgo builds with the voltage_stub build tag, so the cgo backend
+ * can be tested on machines without a Voltage installation; IsMockMode reports
+ * true for such builds.
+ * voltage_init reads the cryptIds and key versions from the XML configuration
+ * the Go side passes with voltage_stub_set_xml_config (or from the XMLConfig
+ * line of the .cfg file), and every operation rejects cryptIds and key versions
+ * that are not defined there.
+ * Protect/access apply a reversible per-position character rotation that keeps
+ * digits as digits and letters as letters, and the binary functions XOR the
+ * data with a keystream seeded from the cryptID and key version. It provides
+ * NO security.
+ */
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include "voltage.h"
+
+#define STUB_VERSION "1.0.0-stub"
+
+/* Error codes, mirrored from errors.go */
+#define STUB_OK                     0
+#define STUB_INVALID_PARAMETER      1
+#define STUB_MEMORY_ALLOCATION      2
+#define STUB_CONFIG_NOT_FOUND       3
+#define STUB_NOT_INITIALIZED        6
+#define STUB_ALREADY_INITIALIZED    7
+#define STUB_CRYPT_ID_NOT_FOUND     10
+#define STUB_KEY_NOT_FOUND          17
+
+#define STUB_MAX_CRYPT_IDS  64
+#define STUB_MAX_VERSIONS   8
+#define STUB_MAX_NAME       128
+
+/* stub_crypt_id is a cryptId from the configuration; versions[0] is the current key version */
+typedef struct {
+    char name[STUB_MAX_NAME];
+    char versions[STUB_MAX_VERSIONS][STUB_MAX_NAME];
+    int version_count;
+} stub_crypt_id;
+
+static int stub_initialized = 0;
+static stub_crypt_id stub_crypt_ids[STUB_MAX_CRYPT_IDS];
+static int stub_crypt_id_count = 0;
+static char stub_xml_config[1024];
+
+static int stub_fail(int code, const char* message, char** error_msg) {
+    if (error_msg != NULL) {
+        *error_msg = strdup(message);
+    }
+    return code;
+}
+
+/* stub_read_file returns the NUL-terminated contents of path, or NULL */
+static char* stub_read_file(const char* path) {
+    FILE* f = fopen(path, "rb");
+    char* data;
+    long size;
+
+    if (f == NULL) {
+        return NULL;
+    }
+    if (fseek(f, 0, SEEK_END) != 0 || (size = ftell(f)) < 0 || fseek(f, 0, SEEK_SET) != 0) {
+        fclose(f);
+        return NULL;
+    }
+    data = malloc((size_t)size + 1);
+    if (data != NULL) {
+        size_t n = fread(data, 1, (size_t)size, f);
+        data[n] = '\0';
+    }
+    fclose(f);
+    return data;
+}
+
+/* stub_copy copies the text between start and end into dst, trimming surrounding whitespace */
+static void stub_copy(char* dst, const char* start, const char* end) {
+    size_t n;
+
+    while (start < end && strchr(" \t\r\n", *start) != NULL) {
+        start++;
+    }
+    while (end > start && strchr(" \t\r\n", end[-1]) != NULL) {
+        end--;
+    }
+    n = (size_t)(end - start);
+    if (n >= STUB_MAX_NAME) {
+        n = STUB_MAX_NAME - 1;
+    }
+    memcpy(dst, start, n);
+    dst[n] = '\0';
+}
+
+/* stub_attr copies the value of attribute attr within [start, end) into dst */
+static int stub_attr(const char* start, const char* end, const char* attr, char* dst) {
+    size_t len = strlen(attr);
+    const char* p;
+
+    for (p = start; p + len + 2 < end; p++) {
+        if (strncmp(p, attr, len) == 0 && p[len] == '=' && p[len + 1] == '"' &&
+            (p == start || strchr(" \t\r\n", p[-1]) != NULL)) {
+            const char* value = p + len + 2;
+            const char* close = memchr(value, '"', (size_t)(end - value));
+            if (close == NULL) {
+                return 0;
+            }
+            stub_copy(dst, value, close);
+            return 1;
+        }
+    }
+    return 0;
+}
+
+/* stub_find is strstr limited to [start, end) */
+static const char* stub_find(const char* start, const char* end, const char* needle) {
+    size_t len = strlen(needle);
+    const char* p;
+
+    for (p = start; p + len <= end; p++) {
+        if (strncmp(p, needle, len) == 0) {
+            return p;
+        }
+    }
+    return NULL;
+}
+
+/* stub_parse_xml records the <cryptId> elements of a vsconfig.xml document */
+static void stub_parse_xml(const char* xml) {
+    const char* p = xml;
+    const char* end = xml + strlen(xml);
+
+    while ((p = strstr(p, "<cryptId")) != NULL && stub_crypt_id_count < STUB_MAX_CRYPT_IDS) {
+        stub_crypt_id* id = &stub_crypt_ids[stub_crypt_id_count];
+        const char* tag_end = strchr(p, '>');
+        const char* body_end;
+        const char* q;
+
+        if (tag_end == NULL) {
+            return;
+        }
+        memset(id, 0, sizeof(*id));
+        if (!stub_attr(p, tag_end, "name", id->name) || id->name[0] == '\0') {
+            p = tag_end;
+            continue;
+        }
+        id->version_count = 1;
+
+        body_end = tag_end;
+        if (tag_end[-1] != '/') {
+            body_end = stub_find(tag_end, end, "</cryptId>");
+            if (body_end == NULL) {
+                body_end = end;
+            }
+            q = stub_find(tag_end, body_end, "<keyVersion>");
+            if (q != NULL) {
+                const char* close = stub_find(q, body_end, "</keyVersion>");
+                if (close != NULL) {
+                    stub_copy(id->versions[0], q + strlen("<keyVersion>"), close);
+                }
+            }
+            for (q = tag_end; (q = stub_find(q, body_end, "<previousKey")) != NULL; q++) {
+                const char* prev_end = stub_find(q, body_end, ">");
+                if (prev_end != NULL && id->version_count < STUB_MAX_VERSIONS &&
+                    stub_attr(q, prev_end, "version", id->versions[id->version_count])) {
+                    id->version_count++;
+                }
+            }
+        }
+        stub_crypt_id_count++;
+        p = body_end;
+    }
+}
+
+/* voltage_stub_set_xml_config names the vsconfig.xml to load at the next voltage_init, or "" for none */
+void voltage_stub_set_xml_config(const char* xml_config) {
+    size_t n;
+
+    if (xml_config == NULL) {
+        xml_config = "";
+    }
+    n = strlen(xml_config);
+    if (n >= sizeof(stub_xml_config)) {
+        n = sizeof(stub_xml_config) - 1;
+    }
+    memcpy(stub_xml_config, xml_config, n);
+    stub_xml_config[n] = '\0';
+}
+
+/* stub_load_xml records the cryptIds of the vsconfig.xml at path */
+static int stub_load_xml(const char* path, char** error_msg) {
+    char* data = stub_read_file(path);
+
+    if (data == NULL) {
+        return stub_fail(STUB_CONFIG_NOT_FOUND, "XML configuration file not found", error_msg);
+    }
+    stub_parse_xml(data);
+    free(data);
+    return STUB_OK;
+}
+
+/*
+ * stub_load_config reads the cryptIds like the Go MockBackend does: from the XML
+ * configuration set with voltage_stub_set_xml_config, or else from config_file
+ * (an XML file or a .cfg file with an XMLConfig line). The .cfg file itself is
+ * optional, without any XML configuration no cryptIds are defined
+ */
+static int stub_load_config(const char* config_file, char** error_msg) {
+    char xml_path[1024];
+    const char* line;
+    char* data;
+    size_t n;
+
+    stub_crypt_id_count = 0;
+    if (stub_xml_config[0] != '\0') {
+        return stub_load_xml(stub_xml_config, error_msg);
+    }
+
+    data = stub_read_file(config_file);
+    if (data == NULL) {
+        return STUB_OK;
+    }
+    if (strstr(data, "<cryptId") != NULL) {
+        stub_parse_xml(data);
+        free(data);
+        return STUB_OK;
+    }
+
+    line = strstr(data, "XMLConfig=");
+    while (line != NULL && line != data && line[-1] != '\n') {
+        line = strstr(line + 1, "XMLConfig=");
+    }
+    if (line == NULL) {
+        free(data);
+        return STUB_OK;
+    }
+    line += strlen("XMLConfig=");
+    n = strcspn(line, "\r\n");
+    if (n >= sizeof(xml_path)) {
+        n = sizeof(xml_path) - 1;
+    }
+    memcpy(xml_path, line, n);
+    xml_path[n] = '\0';
+    free(data);
+
+    return stub_load_xml(xml_path, error_msg);
+}
+
+int voltage_init(const char* config_file, char** error_msg) {
+    int code;
+
+    if (config_file == NULL || config_file[0] == '\0') {
+        return stub_fail(STUB_INVALID_PARAMETER, "config file path is empty", error_msg);
+    }
+    if (stub_initialized) {
+        return stub_fail(STUB_ALREADY_INITIALIZED, "library already initialized", error_msg);
+    }
+    code = stub_load_config(config_file, error_msg);
+    if (code != STUB_OK) {
+        return code;
+    }
+    stub_initialized = 1;
+    return STUB_OK;
+}
+
+/*
+ * stub_seed hashes the cryptID and key version into a keystream seed
+ * A NULL key_version selects the current key. Unknown cryptIds and versions are rejected
+ */
+static int stub_seed(const char* crypt_id, const char* key_version, unsigned int* seed, char** error_msg) {
+    const stub_crypt_id* id = NULL;
+    const char* version;
+    unsigned int h = 2166136261u;
+    int i;
+
+    if (!stub_initialized) {
+        return stub_fail(STUB_NOT_INITIALIZED, "library not initialized", error_msg);
+    }
+    if (crypt_id == NULL || crypt_id[0] == '\0') {
+        return stub_fail(STUB_CRYPT_ID_NOT_FOUND, "cryptId is empty", error_msg);
+    }
+    for (i = 0; i < stub_crypt_id_count; i++) {
+        if (strcmp(stub_crypt_ids[i].name, crypt_id) == 0) {
+            id = &stub_crypt_ids[i];
+            break;
+        }
+    }
+    if (id == NULL) {
+        return stub_fail(STUB_CRYPT_ID_NOT_FOUND, "cryptId is not defined", error_msg);
+    }
+
+    version = id->versions[0];
+    if (key_version != NULL) {
+        for (i = 0; i < id->version_count && strcmp(id->versions[i], key_version) != 0; i++) {
+        }
+        if (i == id->version_count) {
+            return stub_fail(STUB_KEY_NOT_FOUND, "key version is not defined for cryptId", error_msg);
+        }
+        version = id->versions[i];
+    }
+
+    for (i = 0; crypt_id[i] != '\0'; i++) {
+        h = (h ^ (unsigned char)crypt_id[i]) * 16777619u;
+    }
+    h = (h ^ '/') * 16777619u;
+    for (i = 0; version[i] != '\0'; i++) {
+        h = (h ^ (unsigned char)version[i]) * 16777619u;
+    }
+    *seed = h;
+    return STUB_OK;
+}
+
+int voltage_terminate(char** error_msg) {
+    (void)error_msg;
+    stub_initialized = 0;
+    stub_crypt_id_count = 0;
+    return STUB_OK;
+}
+
+int voltage_health_check(char** error_msg) {
+    if (!stub_initialized) {
+        return stub_fail(STUB_NOT_INITIALIZED, "library not initialized", error_msg);
+    }
+    return STUB_OK;
+}
+
+/* stub_rotate shifts each digit/letter by an offset derived from its position, the cryptID and the key version */
+static int stub_rotate(const char* crypt_id, const char* key_version, const char* input,
+                       char** output, char** error_msg, int direction) {
+    size_t i, len;
+    unsigned int seed;
+    char* out;
+    int code;
+
+    code = stub_seed(crypt_id, key_version, &seed, error_msg);
+    if (code != STUB_OK) {
+        return code;
+    }
+    if (input == NULL || output == NULL) {
+        return stub_fail(STUB_INVALID_PARAMETER, "input and output are required", error_msg);
+    }
+
+    len = strlen(input);
+    out = malloc(len + 1);
+    if (out == NULL) {
+        return stub_fail(STUB_MEMORY_ALLOCATION, "out of memory", error_msg);
+    }
+
+    for (i = 0; i < len; i++) {
+        unsigned char ch = (unsigned char)input[i];
+        int shift = (int)((seed + i * 7 + 3) % 26) + 1;
+        if (ch >= '0' && ch <= '9') {
+            out[i] = (char)('0' + ((ch - '0') + 10 + direction * (shift % 9 + 1)) % 10);
+        } else if (ch >= 'a' && ch <= 'z') {
+            out[i] = (char)('a' + ((ch - 'a') + 26 + direction * (shift % 25 + 1)) % 26);
+        } else if (ch >= 'A' && ch <= 'Z') {
+            out[i] = (char)('A' + ((ch - 'A') + 26 + direction * (shift % 25 + 1)) % 26);
+        } else {
+            out[i] = (char)ch;
+        }
+    }
+    out[len] = '\0';
+
+    *output = out;
+    return STUB_OK;
+}
+
+int voltage_protect(const char* crypt_id, const char* input, char** output, char** error_msg) {
+    return stub_rotate(crypt_id, NULL, input, output, error_msg, 1);
+}
+
+int voltage_access(const char* crypt_id, const char* input, char** output, char** error_msg) {
+    return stub_rotate(crypt_id, NULL, input, output, error_msg, -1);
+}
+
+int voltage_access_version(const char* crypt_id, const char* key_version, const char* input,
+                           char** output, char** error_msg) {
+    if (key_version == NULL || key_version[0] == '\0') {
+        return stub_fail(STUB_INVALID_PARAMETER, "key version is empty", error_msg);
+    }
+    return stub_rotate(crypt_id, key_version, input, output, error_msg, -1);
+}
+
+/* stub_batch applies stub_rotate to every input */
+static int stub_batch(const char* crypt_id, const char** inputs, size_t count,
+                      char** outputs, int* codes, char** error_msgs, char** error_msg, int direction) {
+    size_t i;
+
+    if (!stub_initialized) {
+        return stub_fail(STUB_NOT_INITIALIZED, "library not initialized", error_msg);
+    }
+    if (count > 0 && (inputs == NULL || outputs == NULL || codes == NULL || error_msgs == NULL)) {
+        return stub_fail(STUB_INVALID_PARAMETER, "batch arrays are required", error_msg);
+    }
+
+    for (i = 0; i < count; i++) {
+        outputs[i] = NULL;
+        error_msgs[i] = NULL;
+        codes[i] = stub_rotate(crypt_id, NULL, inputs[i], &outputs[i], &error_msgs[i], direction);
+    }
+    return STUB_OK;
+}
+
+int voltage_protect_batch(const char* crypt_id, const char** inputs, size_t count,
+                          char** outputs, int* codes, char** error_msgs, char** error_msg) {
+    return stub_batch(crypt_id, inputs, count, outputs, codes, error_msgs, error_msg, 1);
+}
+
+int voltage_access_batch(const char* crypt_id, const char** inputs, size_t count,
+                         char** outputs, int* codes, char** error_msgs, char** error_msg) {
+    return stub_batch(crypt_id, inputs, count, outputs, codes, error_msgs, error_msg, -1);
+}
+
+/* stub_xor applies a keystream seeded from the cryptID and key version; it is its own inverse */
+static int stub_xor(const char* crypt_id, const char* key_version, const unsigned char* input, size_t input_len,
+                    unsigned char** output, size_t* output_len, char** error_msg) {
+    size_t i;
+    unsigned int state;
+    unsigned char* out;
+    int code;
+
+    code = stub_seed(crypt_id, key_version, &state, error_msg);
+    if (code != STUB_OK) {
+        return code;
+    }
+    if ((input == NULL && input_len > 0) || output == NULL || output_len == NULL) {
+        return stub_fail(STUB_INVALID_PARAMETER, "input and output are required", error_msg);
+    }
+
+    out = malloc(input_len > 0 ? input_len : 1);
+    if (out == NULL) {
+        return stub_fail(STUB_MEMORY_ALLOCATION, "out of memory", error_msg);
+    }
+
+    for (i = 0; i < input_len; i++) {
+        state = state * 1103515245u + 12345u;
+        out[i] = input[i] ^ (unsigned char)(state >> 16);
+    }
+
+    *output = out;
+    *output_len = input_len;
+    return STUB_OK;
+}
+
+int voltage_protect_bytes(const char* crypt_id, const unsigned char* input, size_t input_len,
+                          unsigned char** output, size_t* output_len, char** error_msg) {
+    return stub_xor(crypt_id, NULL, input, input_len, output, output_len, error_msg);
+}
+
+int voltage_access_bytes(const char* crypt_id, const unsigned char* input, size_t input_len,
+                         unsigned char** output, size_t* output_len, char** error_msg) {
+    return stub_xor(crypt_id, NULL, input, input_len, output, output_len, error_msg);
+}
+
+int voltage_access_bytes_version(const char* crypt_id, const char* key_version,
+                                 const unsigned char* input, size_t input_len,
+                                 unsigned char** output, size_t* output_len, char** error_msg) {
+    if (key_version == NULL || key_version[0] == '\0') {
+        return stub_fail(STUB_INVALID_PARAMETER, "key version is empty", error_msg);
+    }
+    return stub_xor(crypt_id, key_version, input, input_len, output, output_len, error_msg);
+}
+
+const char* voltage_get_version(void) {
+    return STUB_VERSION;
+}
@@ -1,6 +1,8 @@
 package vlock
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -375,3 +377,91 @@ func TestClientLifecycle(t *testing.T) {
 		t.Error("Client should not be initialized after close")
 	}
 }
+
+func TestProtectAccessText(t *testing.T) {
+	cfg := &config.Config{
+		AppName:         "TestApp",
+		AppVersion:      "1.0.0",
+		AppEnv:          "DEV",
+		DEKSharedSecret: "test_secret",
+		ConfigFilePath:  "test.cfg",
+		XMLConfigPath:   "../config/dev/vsconfig.xml",
+		DefaultCryptID:  "SSN_Internal",
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+
+	// Operations before initialization should fail
+	if _, err := client.ProtectText(ctx, "SSN_Internal", "123-45-6789"); !errors.Is(err, ErrClientNotInitialized) {
+		t.Errorf("Expected ErrClientNotInitialized before initialization, got: %v", err)
+	}
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	protected, err := client.ProtectText(ctx, "SSN_Internal", "123-45-6789")
+	if err != nil {
+		t.Fatalf("ProtectText failed: %v", err)
+	}
+	if protected == "123-45-6789" {
+		t.Error("Protected value should differ from plaintext")
+	}
+
+	// Empty cryptID falls back to DefaultCryptID
+	accessed, err := client.AccessText(ctx, "", protected)
+	if err != nil {
+		t.Fatalf("AccessText failed: %v", err)
+	}
+	if accessed != "123-45-6789" {
+		t.Errorf("Expected '123-45-6789', got '%s'", accessed)
+	}
+
+	// Empty input is rejected
+	var voltageErr *VoltageError
+	if _, err := client.ProtectText(ctx, "SSN_Internal", ""); !errors.As(err, &voltageErr) || voltageErr.Code != ErrInvalidData {
+		t.Errorf("Expected ErrInvalidData for empty plaintext, got: %v", err)
+	}
+
+	// Cancelled contexts are honored
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := client.ProtectText(cancelled, "SSN_Internal", "123-45-6789"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestProtectTextWithoutDefaultCryptID(t *testing.T) {
+	cfg := &config.Config{
+		AppName:         "TestApp",
+		AppVersion:      "1.0.0",
+		AppEnv:          "DEV",
+		DEKSharedSecret: "test_secret",
+		ConfigFilePath:  "test.cfg",
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+
+	_, err = client.ProtectText(context.Background(), "", "123-45-6789")
+	var voltageErr *VoltageError
+	if !errors.As(err, &voltageErr) {
+		t.Fatalf("Expected *VoltageError, got: %v", err)
+	}
+	if voltageErr.Code != ErrCryptIDNotFound {
+		t.Errorf("Expected ErrCryptIDNotFound, got code %d", voltageErr.Code)
+	}
+}